@@ -0,0 +1,33 @@
+package main
+
+import "os"
+
+func yellow(s string) string {
+	return "\033[33m" + s + "\033[0m"
+}
+func red(s string) string {
+	return "\033[31m" + s + "\033[0m"
+}
+func green(s string) string {
+	return "\033[32m" + s + "\033[0m"
+}
+func bold(s string) string {
+	return "\033[1m" + s + "\033[0m"
+}
+
+// Whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Resolves a -color flag value (auto, always, never) for output to f.
+func useColor(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return isTerminal(f) && os.Getenv("NO_COLOR") == ""
+}
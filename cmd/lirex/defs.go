@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	lx "lirex/lirex"
+)
+
+// Definition files describe an expression line by line; the nodes are concatenated:
+//
+//	# comment
+//	capture user regex [\w.]+
+//	lit @
+//	helper Domain
+//
+// Kinds:
+//
+//	helper NAME            a field of lx.Helpers, e.g. Email
+//	regex PATTERN          a Go regex, see lx.Parse; leading flags turn on Options
+//	lit TEXT               literal text, Go-quoted if it starts with "
+//	capture NAME KIND ARG  the node of KIND ARG captured as NAME
+func loadDefinition(r io.Reader, filename string) (lx.ExpTreeNode, lx.Options, error) {
+	tree := lx.ExpTreeNode{}
+	opts := lx.Options{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		node, err := definitionNode(line, &opts)
+		if err != nil {
+			return nil, lx.Options{}, fmt.Errorf("%s:%d: %w", filename, lineNo, err)
+		}
+		tree = append(tree, node)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, lx.Options{}, err
+	}
+	if len(tree) == 0 {
+		return nil, lx.Options{}, fmt.Errorf("%s: no definitions", filename)
+	}
+	return tree, opts, nil
+}
+
+func definitionNode(line string, opts *lx.Options) (lx.Node, error) {
+	kind, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return nil, fmt.Errorf("%s needs an argument", kind)
+	}
+	switch kind {
+	case "helper":
+		helper, ok := lx.LookupHelper(arg)
+		if !ok {
+			return nil, fmt.Errorf("unknown helper %q (known: %s)", arg, strings.Join(lx.HelperNames(), ", "))
+		}
+		return helper, nil
+	case "regex":
+		tree, regexOpts, err := lx.Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		opts.CaseInsensitive = opts.CaseInsensitive || regexOpts.CaseInsensitive
+		opts.Multiline = opts.Multiline || regexOpts.Multiline
+		opts.DotMatchesNewline = opts.DotMatchesNewline || regexOpts.DotMatchesNewline
+		return lx.Seq(tree...), nil
+	case "lit":
+		if strings.HasPrefix(arg, `"`) {
+			text, err := strconv.Unquote(arg)
+			if err != nil {
+				return nil, fmt.Errorf("lit: %w", err)
+			}
+			return lx.Lit(text), nil
+		}
+		return lx.Lit(arg), nil
+	case "capture":
+		name, rest, _ := strings.Cut(arg, " ")
+		node, err := definitionNode(strings.TrimSpace(rest), opts)
+		if err != nil {
+			return nil, fmt.Errorf("capture %s: %w", name, err)
+		}
+		return lx.Capture(name, node), nil
+	}
+	return nil, fmt.Errorf("unknown kind %q (helper, regex, lit or capture)", kind)
+}
+
+// The expression of a definition file if defFile is set, else the parsed Go regex pattern.
+func loadExpression(pattern, defFile string) (lx.ExpTreeNode, lx.Options, error) {
+	if defFile == "" {
+		return lx.Parse(pattern)
+	}
+	f, err := os.Open(defFile)
+	if err != nil {
+		return nil, lx.Options{}, err
+	}
+	defer f.Close()
+	return loadDefinition(f, defFile)
+}
+
+// The Go regexes an expression is parsed from: pattern itself, or the regex definitions of defFile.
+func regexSources(pattern, defFile string) ([]string, error) {
+	if defFile == "" {
+		return []string{pattern}, nil
+	}
+	f, err := os.Open(defFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sources := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		kind, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		for kind == "capture" {
+			_, rest, _ := strings.Cut(strings.TrimSpace(arg), " ")
+			kind, arg, _ = strings.Cut(strings.TrimSpace(rest), " ")
+		}
+		if kind == "regex" {
+			sources = append(sources, strings.TrimSpace(arg))
+		}
+	}
+	return sources, scanner.Err()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified diff of a and b with 3 lines of context, as diff -u prints it. "" if they are equal.
+func unifiedDiff(nameA, nameB, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+	// Line numbers in a and b where each op starts
+	posA, posB := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	posA[0], posB[0] = 1, 1
+	for i, op := range ops {
+		posA[i+1], posB[i+1] = posA[i], posB[i]
+		if op.kind != '+' {
+			posA[i+1]++
+		}
+		if op.kind != '-' {
+			posB[i+1]++
+		}
+	}
+	const context = 3
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Changes less than 7 unchanged lines apart share a hunk.
+		start, end := max(i-context, 0), i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				if j-end > 2*context {
+					break
+				}
+				end = j + 1
+			}
+		}
+		end = min(end+context, len(ops))
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(posA[start], posA[end]-posA[start]), hunkRange(posB[start], posB[end]-posB[start]))
+		for _, op := range ops[start:end] {
+			out.WriteString(string(op.kind) + op.line + "\n")
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Shortest edit script from a to b (Myers' algorithm).
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	trace := [][]int{}
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset, d)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int, offset, d int) []diffOp {
+	ops := []diffOp{}
+	x, y := len(a), len(b)
+	for ; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
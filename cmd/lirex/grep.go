@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type grepMatch struct {
+	file string
+	line int
+	// 1-based and counted in runes, like the Column of lirex tokens
+	col      int
+	text     string
+	start    int
+	end      int
+	captures map[string]string
+}
+
+type grepOutput interface {
+	write(m grepMatch) error
+	flush() error
+}
+
+func runGrep(args []string) int {
+	flags := flag.NewFlagSet("grep", flag.ContinueOnError)
+	pattern := flags.String("e", "", "Go regex to search for (parsed into lirex nodes)")
+	defFile := flags.String("f", "", "definition file to load the expression from")
+	format := flags.String("format", "plain", "output format: plain, json or csv")
+	fields := flags.String("fields", "", "comma-separated captures for csv (default: all named captures)")
+	color := flags.String("color", "auto", "highlight matches: auto, always or never")
+	ignoreCase := flags.Bool("i", false, "case-insensitive")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: lirex grep [flags] (PATTERN | -e PATTERN | -f FILE) [FILE...]\n\nMatches are reported as file:line:col; lines and columns start at 1 and columns count runes.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	files := flags.Args()
+	if *pattern == "" && *defFile == "" {
+		if len(files) == 0 {
+			flags.Usage()
+			return 2
+		}
+		*pattern, files = files[0], files[1:]
+	}
+
+	re, err := grepExpression(*pattern, *defFile, *ignoreCase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lirex grep:", err)
+		return 2
+	}
+
+	var out grepOutput
+	switch *format {
+	case "plain":
+		out = &plainOutput{w: bufio.NewWriter(os.Stdout), color: useColor(*color, os.Stdout), names: len(files) > 1}
+	case "json":
+		out = &jsonOutput{w: bufio.NewWriter(os.Stdout)}
+	case "csv":
+		out = newCSVOutput(os.Stdout, captureNames(re, *fields))
+	default:
+		fmt.Fprintf(os.Stderr, "lirex grep: unknown format %q\n", *format)
+		return 2
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	matched, failed := false, false
+	for _, file := range files {
+		found, err := grepFile(re, file, out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "lirex grep:", err)
+			failed = true
+		}
+		matched = matched || found
+	}
+	if err := out.flush(); err != nil {
+		fmt.Fprintln(os.Stderr, "lirex grep:", err)
+		return 2
+	}
+	switch {
+	case failed:
+		return 2
+	case matched:
+		return 0
+	}
+	return 1
+}
+
+func grepExpression(pattern, defFile string, ignoreCase bool) (*regexp.Regexp, error) {
+	tree, opts, err := loadExpression(pattern, defFile)
+	if err != nil {
+		return nil, err
+	}
+	opts.CaseInsensitive = opts.CaseInsensitive || ignoreCase
+	return tree.Compile(opts)
+}
+
+func grepFile(re *regexp.Regexp, file string, out grepOutput) (bool, error) {
+	var r io.Reader = os.Stdin
+	name := "(standard input)"
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		r, name = f, file
+	}
+
+	found := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	names := re.SubexpNames()
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		text := scanner.Text()
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			found = true
+			m := grepMatch{file: name, line: lineNo, col: utf8.RuneCountInString(text[:loc[0]]) + 1, text: text, start: loc[0], end: loc[1], captures: matchCaptures(names, text, loc)}
+			if err := out.write(m); err != nil {
+				return found, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return found, fmt.Errorf("%s: %w", name, err)
+	}
+	return found, nil
+}
+
+// Participating named groups of a match; for duplicate names the first one that took part wins.
+func matchCaptures(names []string, text string, loc []int) map[string]string {
+	captures := map[string]string{}
+	for i, name := range names {
+		if _, seen := captures[name]; name == "" || seen || loc[2*i] < 0 {
+			continue
+		}
+		captures[name] = text[loc[2*i]:loc[2*i+1]]
+	}
+	return captures
+}
+
+// Named captures of re, or the ones listed in fields.
+func captureNames(re *regexp.Regexp, fields string) []string {
+	if fields != "" {
+		return strings.Split(fields, ",")
+	}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range re.SubexpNames() {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// OUTPUT -------------------------------------------------------------------------------
+// file:line:col: text, with the match highlighted on terminals. The file is left out for a single input.
+type plainOutput struct {
+	w     *bufio.Writer
+	color bool
+	names bool
+}
+
+func (o *plainOutput) write(m grepMatch) error {
+	position := fmt.Sprintf("%d:%d:", m.line, m.col)
+	text := m.text
+	file := m.file + ":"
+	if o.color {
+		position, file = yellow(position), green(file)
+		text = text[:m.start] + bold(red(text[m.start:m.end])) + text[m.end:]
+	}
+	if o.names {
+		position = file + position
+	}
+	_, err := fmt.Fprintln(o.w, position, text)
+	return err
+}
+func (o *plainOutput) flush() error { return o.w.Flush() }
+
+// One JSON object per match.
+type jsonOutput struct {
+	w *bufio.Writer
+}
+
+func (o *jsonOutput) write(m grepMatch) error {
+	b, err := json.Marshal(struct {
+		File     string            `json:"file"`
+		Line     int               `json:"line"`
+		Col      int               `json:"col"`
+		Match    string            `json:"match"`
+		Captures map[string]string `json:"captures"`
+	}{m.file, m.line, m.col, m.text[m.start:m.end], m.captures})
+	if err != nil {
+		return err
+	}
+	o.w.Write(b)
+	return o.w.WriteByte('\n')
+}
+func (o *jsonOutput) flush() error { return o.w.Flush() }
+
+// file,line,col and the selected captures, with a header row.
+type csvOutput struct {
+	w      *csv.Writer
+	fields []string
+	header bool
+}
+
+func newCSVOutput(w io.Writer, fields []string) *csvOutput {
+	return &csvOutput{w: csv.NewWriter(w), fields: fields}
+}
+
+func (o *csvOutput) write(m grepMatch) error {
+	if !o.header {
+		o.header = true
+		if err := o.w.Write(append([]string{"file", "line", "col"}, o.fields...)); err != nil {
+			return err
+		}
+	}
+	record := []string{m.file, strconv.Itoa(m.line), strconv.Itoa(m.col)}
+	for _, field := range o.fields {
+		record = append(record, m.captures[field])
+	}
+	return o.w.Write(record)
+}
+func (o *csvOutput) flush() error {
+	o.w.Flush()
+	return o.w.Error()
+}
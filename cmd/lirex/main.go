@@ -0,0 +1,53 @@
+// Command lirex works with lirex expressions from the shell.
+//
+//	lirex grep [flags] (PATTERN | -e PATTERN | -f FILE) [FILE...]
+//	lirex explain [flags] (PATTERN | -f FILE)
+//	lirex test [flags] (PATTERN | -f FILE) INPUT...
+//	lirex to-go [flags] (PATTERN | -f FILE)
+//	lirex compile FILE...
+//	lirex migrate [-w] [PATH...]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"grep", "print matches of an expression in files or stdin", runGrep},
+	{"explain", "print the node tree and an English description of a pattern", runExplain},
+	{"test", "show the matches and captures of a pattern in each input", runTest},
+	{"to-go", "print lirex builder code for a regex", runToGo},
+	{"compile", "print the regex a definition file compiles to", runCompile},
+	{"migrate", "rewrite regexp.MustCompile calls in Go files as lirex expressions", runMigrate},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+	if os.Args[1] != "help" && os.Args[1] != "-h" && os.Args[1] != "--help" {
+		fmt.Fprintf(os.Stderr, "lirex: unknown command %q\n", os.Args[1])
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: lirex <command> [arguments]\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+}
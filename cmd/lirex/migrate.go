@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+
+	lx "lirex/lirex"
+)
+
+// MIGRATE ------------------------------------------------------------------------------
+// Rewrites regexp.MustCompile and regexp.Compile calls with constant patterns into lirex
+// expressions. Without -w it prints a diff and changes nothing.
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the changes to the files instead of printing a diff")
+	pkg := flags.String("pkg", "lx", "name to import lirex as")
+	importPath := flags.String("import", "lirex/lirex", "import path of the lirex package")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: lirex migrate [flags] [PATH...]\n\nDirectories are walked recursively, skipping vendor and testdata.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := goFiles(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lirex migrate:", err)
+		return 2
+	}
+
+	m := migrator{pkg: *pkg, importPath: *importPath}
+	status := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "lirex migrate:", err)
+			status = 2
+			continue
+		}
+		migrated, err := m.file(file, src)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "lirex migrate:", err)
+			status = 2
+			continue
+		}
+		if bytes.Equal(src, migrated) {
+			continue
+		}
+		if !*write {
+			fmt.Print(unifiedDiff("a/"+filepath.ToSlash(file), "b/"+filepath.ToSlash(file), string(src), string(migrated)))
+			continue
+		}
+		if err := os.WriteFile(file, migrated, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, "lirex migrate:", err)
+			status = 2
+		}
+	}
+	return status
+}
+
+// .go files of paths, sorted.
+func goFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		path = strings.TrimSuffix(path, "/...")
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if file != path && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(name, ".go") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+type migrator struct {
+	pkg        string
+	importPath string
+}
+
+type edit struct {
+	start, end int
+	text       string
+}
+
+// src with its regexp calls rewritten, gofmt'ed. Calls that can't be migrated are reported on
+// stderr and left alone.
+func (m migrator) file(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	regexpName, regexpImport := importName(f, "regexp")
+	if regexpImport == nil {
+		return src, nil
+	}
+	pkg := m.pkg
+	if name, spec := importName(f, m.importPath); spec != nil {
+		pkg = name
+	}
+
+	edits := []edit{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "MustCompile" && sel.Sel.Name != "Compile") {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); !ok || x.Name != regexpName || x.Obj != nil {
+			return true
+		}
+		pattern, ok := constString(call.Args[0])
+		if !ok {
+			return true
+		}
+		code, err := m.expression(pattern, sel.Sel.Name, pkg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: skipped %s: %v\n", fset.Position(call.Pos()), strconv.Quote(pattern), err)
+			return true
+		}
+		edits = append(edits, edit{fset.Position(call.Pos()).Offset, fset.Position(call.End()).Offset, code})
+		return false
+	})
+	if len(edits) == 0 {
+		return src, nil
+	}
+
+	// Imports: add lirex, and drop regexp once nothing else refers to it.
+	decl := importDecl(f, regexpImport)
+	remove := edit{fset.Position(regexpImport.Pos()).Offset, fset.Position(regexpImport.End()).Offset, ""}
+	if !decl.Lparen.IsValid() {
+		remove.start, remove.end = fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+	}
+	keepRegexp := usesPackage(f, regexpName, edits, fset)
+	if _, spec := importName(f, m.importPath); spec == nil {
+		name := ""
+		if pkg != lastElement(m.importPath) {
+			name = pkg + " "
+		}
+		spec := name + strconv.Quote(m.importPath)
+		if !decl.Lparen.IsValid() {
+			spec = "import " + spec
+		}
+		switch {
+		case keepRegexp && decl.Lparen.IsValid():
+			end := fset.Position(decl.Rparen).Offset
+			edits = append(edits, edit{end, end, "\n" + spec + "\n"})
+		case keepRegexp:
+			edits = append(edits, edit{remove.end, remove.end, "\n" + spec})
+		default:
+			edits = append(edits, edit{remove.start, remove.end, spec})
+		}
+	} else if !keepRegexp {
+		edits = append(edits, remove)
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		out.Write(src[last:e.start])
+		out.WriteString(e.text)
+		last = e.end
+	}
+	out.Write(src[last:])
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return formatted, nil
+}
+
+// lx.Exp(...).MustCompile(lx.Options{...}) for pattern, or why it is left as is.
+func (m migrator) expression(pattern, method, pkg string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	// Numbered groups become Group()s; FindStringSubmatch indexes would shift.
+	if hasUnnamedCapture(re) {
+		return "", fmt.Errorf("unnamed capture groups")
+	}
+	tree, opts, err := lx.Parse(pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tree.Compile(opts); err != nil {
+		return "", err
+	}
+	code, err := tree.GoCode(pkg)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s(%s)", code, method, opts.GoCode(pkg)), nil
+}
+
+func hasUnnamedCapture(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpCapture && re.Name == "" {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasUnnamedCapture(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value of a string literal or a concatenation of them.
+func constString(expr ast.Expr) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(expr.Value)
+		return s, err == nil
+	case *ast.ParenExpr:
+		return constString(expr.X)
+	case *ast.BinaryExpr:
+		if expr.Op != token.ADD {
+			return "", false
+		}
+		x, ok := constString(expr.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := constString(expr.Y)
+		return x + y, ok
+	}
+	return "", false
+}
+
+// Local name and spec of the import of path, or a nil spec.
+func importName(f *ast.File, path string) (string, *ast.ImportSpec) {
+	for _, spec := range f.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+			if spec.Name != nil {
+				return spec.Name.Name, spec
+			}
+			return lastElement(path), spec
+		}
+	}
+	return "", nil
+}
+
+// The import declaration holding spec.
+func importDecl(f *ast.File, spec *ast.ImportSpec) *ast.GenDecl {
+	for _, d := range f.Decls {
+		if decl, ok := d.(*ast.GenDecl); ok && decl.Tok == token.IMPORT {
+			for _, s := range decl.Specs {
+				if s == spec {
+					return decl
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func lastElement(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// Whether name.X appears outside the replaced ranges.
+func usesPackage(f *ast.File, name string, edits []edit, fset *token.FileSet) bool {
+	used := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return !used
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == name && x.Obj == nil {
+			offset := fset.Position(sel.Pos()).Offset
+			replaced := false
+			for _, e := range edits {
+				replaced = replaced || (e.start <= offset && offset < e.end)
+			}
+			used = used || !replaced
+		}
+		return !used
+	})
+	return used
+}
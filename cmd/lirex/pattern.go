@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp/syntax"
+	"strconv"
+
+	lx "lirex/lirex"
+)
+
+// Flags shared by the commands that take one expression: a regex argument or -f FILE.
+type patternFlags struct {
+	*flag.FlagSet
+	defFile    *string
+	ignoreCase *bool
+}
+
+func newPatternFlags(name, args string) patternFlags {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	p := patternFlags{
+		FlagSet:    flags,
+		defFile:    flags.String("f", "", "definition file to load the expression from"),
+		ignoreCase: flags.Bool("i", false, "case-insensitive"),
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: lirex %s [flags] %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return p
+}
+
+// Parses args and loads the expression; the remaining arguments are returned.
+func (p patternFlags) load(args []string) (lx.ExpTreeNode, lx.Options, []string, bool) {
+	if err := p.Parse(args); err != nil {
+		return nil, lx.Options{}, nil, false
+	}
+	rest := p.Args()
+	pattern := ""
+	if *p.defFile == "" {
+		if len(rest) == 0 {
+			p.Usage()
+			return nil, lx.Options{}, nil, false
+		}
+		pattern, rest = rest[0], rest[1:]
+	}
+	tree, opts, err := loadExpression(pattern, *p.defFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lirex %s: %v\n", p.Name(), err)
+		return nil, lx.Options{}, nil, false
+	}
+	opts.CaseInsensitive = opts.CaseInsensitive || *p.ignoreCase
+	return tree, opts, rest, true
+}
+
+// EXPLAIN ------------------------------------------------------------------------------
+func runExplain(args []string) int {
+	flags := newPatternFlags("explain", "(PATTERN | -f FILE)")
+	tree, opts, _, ok := flags.load(args)
+	if !ok {
+		return 2
+	}
+	tree.Explain(opts)
+	return 0
+}
+
+// TEST ---------------------------------------------------------------------------------
+// Exits 0 if every input matched, 1 if one did not.
+func runTest(args []string) int {
+	flags := newPatternFlags("test", "(PATTERN | -f FILE) INPUT...")
+	tree, opts, inputs, ok := flags.load(args)
+	if !ok {
+		return 2
+	}
+	re, err := tree.Compile(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lirex test:", err)
+		return 2
+	}
+	color := useColor("auto", os.Stdout)
+	names := captureNames(re, "")
+	status := 0
+	for _, input := range inputs {
+		all := re.FindAllStringSubmatchIndex(input, -1)
+		if len(all) == 0 {
+			result := "no match"
+			if color {
+				result = red(result)
+			}
+			fmt.Printf("%s: %s\n", strconv.Quote(input), result)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %d match(es)\n", strconv.Quote(input), len(all))
+		for _, loc := range all {
+			text := strconv.Quote(input[loc[0]:loc[1]])
+			if color {
+				text = bold(green(text))
+			}
+			fmt.Printf("  [%d:%d] %s\n", loc[0], loc[1], text)
+			captures := matchCaptures(re.SubexpNames(), input, loc)
+			for _, name := range names {
+				if value, ok := captures[name]; ok {
+					fmt.Printf("    %s = %s\n", name, strconv.Quote(value))
+				}
+			}
+		}
+	}
+	return status
+}
+
+// TO-GO --------------------------------------------------------------------------------
+func runToGo(args []string) int {
+	flags := newPatternFlags("to-go", "(PATTERN | -f FILE)")
+	pkg := flags.String("pkg", "lx", "package qualifier of the lirex import")
+	tree, opts, _, ok := flags.load(args)
+	if !ok {
+		return 2
+	}
+	// Like migrate: numbered groups would turn into Group()s and their submatches would be lost.
+	sources, err := regexSources(flags.Arg(0), *flags.defFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lirex to-go:", err)
+		return 2
+	}
+	for _, source := range sources {
+		if re, err := syntax.Parse(source, syntax.Perl); err == nil && hasUnnamedCapture(re) {
+			fmt.Fprintf(os.Stderr, "lirex to-go: %s has unnamed capture groups, which lirex can't keep; name them (?P<name>...) or make them non-capturing (?:...)\n", strconv.Quote(source))
+			return 2
+		}
+	}
+	code, err := tree.GoCode(*pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lirex to-go:", err)
+		return 2
+	}
+	fmt.Printf("%s.MustCompile(%s)\n", code, opts.GoCode(*pkg))
+	return 0
+}
+
+// COMPILE ------------------------------------------------------------------------------
+func runCompile(args []string) int {
+	flags := flag.NewFlagSet("compile", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: lirex compile FILE...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	status := 0
+	for _, file := range flags.Args() {
+		tree, opts, err := loadExpression("", file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "lirex compile:", err)
+			status = 2
+			continue
+		}
+		re, err := tree.Compile(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lirex compile: %s: %v\n", file, err)
+			status = 2
+			continue
+		}
+		fmt.Println(re.String())
+	}
+	return status
+}
@@ -0,0 +1,13 @@
+// Command lirex-vet runs the lirex analyzer, standalone or as a go vet tool:
+//
+//	lirex-vet ./...
+//	go vet -vettool=$(which lirex-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"lirex/lint"
+)
+
+func main() { singlechecker.Main(lint.Analyzer) }
@@ -0,0 +1,13 @@
+module lirex/lint
+
+go 1.25.0
+
+require lirex v0.0.0
+
+require (
+	golang.org/x/mod v0.35.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/tools v0.44.0
+)
+
+replace lirex => ../
@@ -0,0 +1,274 @@
+// Package lint is a go/analysis pass that reports lirex misuse which otherwise only shows up
+// when the expression is compiled: reused helpers, duplicate or reserved capture names,
+// redundant repeats, UnsafeRaw that Lit could express and MustCompile in loops.
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp/syntax"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+
+	lx "lirex/lirex"
+)
+
+const lirexPath = "lirex/lirex"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "lirex",
+	Doc:      "report lirex expressions that fail or misbehave when compiled",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	// lirex builds its helpers from the reserved names.
+	if pass.Pkg.Path() == lirexPath {
+		return nil, nil
+	}
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		switch name := lirexFunc(pass, call); name {
+		case "Exp":
+			checkExp(pass, call)
+		case "Capture":
+			checkReservedName(pass, call)
+		case "Between":
+			checkBetween(pass, call)
+		case "UnsafeRaw":
+			checkUnsafeRaw(pass, call)
+		case "MustCompile":
+			checkLoop(pass, call, stack)
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// Name of the lirex function or method call calls, or "".
+func lirexFunc(pass *analysis.Pass, call *ast.CallExpr) string {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != lirexPath {
+		return ""
+	}
+	return fn.Name()
+}
+
+func constString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+func constUint(pass *analysis.Pass, expr ast.Expr) (uint64, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil {
+		return 0, false
+	}
+	return constant.Uint64Val(constant.ToInt(tv.Value))
+}
+
+// EXP ----------------------------------------------------------------------------------
+// Helpers and capture names seen in one Exp, qualified with their enclosing namespaces.
+type expNames struct {
+	pass     *analysis.Pass
+	helpers  map[string]bool
+	captures map[string]bool
+}
+
+// Helpers are used once per expression and capture names are unique, unless a Namespace
+// sets them apart.
+func checkExp(pass *analysis.Pass, call *ast.CallExpr) {
+	names := expNames{pass: pass, helpers: map[string]bool{}, captures: map[string]bool{}}
+	for _, arg := range call.Args {
+		names.walk(arg, nil)
+	}
+}
+
+func (names expNames) walk(expr ast.Expr, namespace []string) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if field, ok := helperField(names.pass, n); ok {
+				name := qualifiedName(namespace, field)
+				if names.helpers[name] {
+					names.pass.Reportf(n.Pos(), "Helpers.%s is used more than once in this Exp; put each use in its own Namespace", field)
+				}
+				names.helpers[name] = true
+				return false
+			}
+		case *ast.CallExpr:
+			switch lirexFunc(names.pass, n) {
+			case "Namespace":
+				if len(n.Args) == 0 {
+					return true
+				}
+				prefix, ok := constString(names.pass, n.Args[0])
+				if !ok {
+					// Unknown prefix: nothing inside can clash with the rest.
+					prefix = fmt.Sprintf("\x00%d", n.Pos())
+				}
+				inner := append(append([]string(nil), namespace...), prefix)
+				names.walk(n.Fun, namespace)
+				for _, arg := range n.Args[1:] {
+					names.walk(arg, inner)
+				}
+				return false
+			case "Capture":
+				if len(n.Args) == 0 {
+					return true
+				}
+				if capture, ok := constString(names.pass, n.Args[0]); ok {
+					name := qualifiedName(namespace, capture)
+					if names.captures[name] {
+						names.pass.Reportf(n.Args[0].Pos(), "duplicate capture name %q in this Exp", name)
+					}
+					names.captures[name] = true
+				}
+			}
+		}
+		return true
+	})
+}
+
+// Field name of a Helpers.X selector.
+func helperField(pass *analysis.Pass, sel *ast.SelectorExpr) (string, bool) {
+	var id *ast.Ident
+	switch x := sel.X.(type) {
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	default:
+		return "", false
+	}
+	obj, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok || obj.Pkg() == nil || obj.Pkg().Path() != lirexPath || obj.Name() != "Helpers" {
+		return "", false
+	}
+	return sel.Sel.Name, true
+}
+
+func qualifiedName(namespace []string, name string) string {
+	for i := len(namespace) - 1; i >= 0; i-- {
+		name = namespace[i] + "_" + name
+	}
+	return name
+}
+
+// CAPTURE ------------------------------------------------------------------------------
+func checkReservedName(pass *analysis.Pass, call *ast.CallExpr) {
+	if len(call.Args) == 0 {
+		return
+	}
+	name, ok := constString(pass, call.Args[0])
+	if !ok {
+		return
+	}
+	if _, reserved := lx.ReservedGroupNames[name]; reserved {
+		pass.Reportf(call.Args[0].Pos(), "capture name %q is reserved by a lirex helper", name)
+	}
+}
+
+// REPEAT -------------------------------------------------------------------------------
+func checkBetween(pass *analysis.Pass, call *ast.CallExpr) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) != 2 {
+		return
+	}
+	min, minOK := constUint(pass, call.Args[0])
+	max, maxOK := constUint(pass, call.Args[1])
+	if !minOK || !maxOK {
+		return
+	}
+	replacement := ""
+	switch {
+	case min == max:
+		replacement = fmt.Sprintf("Exactly(%d)", min)
+	case min == 0 && max == 1:
+		replacement = "Optional()"
+	default:
+		return
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:     sel.Sel.Pos(),
+		End:     call.End(),
+		Message: fmt.Sprintf("Between(%d, %d) is %s", min, max, replacement),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "Use " + replacement,
+			TextEdits: []analysis.TextEdit{{Pos: sel.Sel.Pos(), End: call.End(), NewText: []byte(replacement)}},
+		}},
+	})
+}
+
+// UNSAFE RAW ---------------------------------------------------------------------------
+func checkUnsafeRaw(pass *analysis.Pass, call *ast.CallExpr) {
+	if len(call.Args) != 1 {
+		return
+	}
+	raw, ok := constString(pass, call.Args[0])
+	if !ok {
+		return
+	}
+	re, err := syntax.Parse(raw, syntax.Perl)
+	if err != nil || re.Op != syntax.OpLiteral || re.Flags&syntax.FoldCase != 0 {
+		return
+	}
+	literal := strconv.Quote(string(re.Rune))
+	fn := call.Fun
+	if sel, ok := fn.(*ast.SelectorExpr); ok {
+		fn = sel.Sel
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: fmt.Sprintf("UnsafeRaw(%s) only matches the text %s; use Lit(%s)", strconv.Quote(raw), literal, literal),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message: "Use Lit",
+			TextEdits: []analysis.TextEdit{
+				{Pos: fn.Pos(), End: fn.End(), NewText: []byte("Lit")},
+				{Pos: call.Args[0].Pos(), End: call.Args[0].End(), NewText: []byte(literal)},
+			},
+		}},
+	})
+}
+
+// LOOPS --------------------------------------------------------------------------------
+// MustCompile inside a loop compiles the same expression on every iteration; init() is
+// exempt since it runs once.
+func checkLoop(pass *analysis.Pass, call *ast.CallExpr, stack []ast.Node) {
+	var loop token.Pos
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			if !loop.IsValid() {
+				loop = n.Pos()
+			}
+		case *ast.FuncLit:
+			i = 0
+		case *ast.FuncDecl:
+			if n.Recv == nil && n.Name.Name == "init" {
+				return
+			}
+			i = 0
+		}
+	}
+	if loop.IsValid() {
+		pass.Reportf(call.Pos(), "MustCompile inside a loop (line %d) compiles on every iteration; compile once outside it",
+			pass.Fset.Position(loop).Line)
+	}
+}
@@ -0,0 +1,11 @@
+package lint
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "testdata/src", Analyzer, "./...")
+}
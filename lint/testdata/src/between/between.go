@@ -0,0 +1,16 @@
+package between
+
+import lx "lirex/lirex"
+
+const four = 4
+
+var (
+	exactly  = lx.Digit.Between(4, 4)       // want `Between\(4, 4\) is Exactly\(4\)`
+	constant = lx.Digit.Between(four, four) // want `Between\(4, 4\) is Exactly\(4\)`
+	optional = lx.Lit("-").Between(0, 1)    // want `Between\(0, 1\) is Optional\(\)`
+	ranged   = lx.Digit.Between(1, 3)
+)
+
+func dynamic(n uint) lx.BetweenRepeatNode {
+	return lx.Digit.Between(n, n)
+}
@@ -0,0 +1,11 @@
+package capture
+
+import lx "lirex/lirex"
+
+var reserved = lx.Capture("Email_domain", lx.WordChar.AtLeast(1)) // want `capture name "Email_domain" is reserved by a lirex helper`
+
+var free = lx.Capture("domain", lx.WordChar.AtLeast(1))
+
+func dynamic(name string) lx.CaptureNode {
+	return lx.Capture(name, lx.WordChar.AtLeast(1))
+}
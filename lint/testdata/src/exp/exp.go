@@ -0,0 +1,37 @@
+package exp
+
+import lx "lirex/lirex"
+
+var helperTwice = lx.Exp(
+	lx.Helpers.Email,
+	lx.Lit(" "),
+	lx.Helpers.Email, // want `Helpers.Email is used more than once in this Exp`
+)
+
+var helperInNamespaces = lx.Exp(
+	lx.Namespace("from", lx.Helpers.Email),
+	lx.Lit(" "),
+	lx.Namespace("to", lx.Helpers.Email),
+)
+
+var captureTwice = lx.Exp(
+	lx.Capture("year", lx.Digit.Exactly(4)),
+	lx.Capture("year", lx.Digit.Exactly(4)), // want `duplicate capture name "year" in this Exp`
+)
+
+var captureInNamespaces = lx.Exp(
+	lx.Namespace("start", lx.Capture("year", lx.Digit.Exactly(4))),
+	lx.Namespace("end", lx.Capture("year", lx.Digit.Exactly(4))),
+)
+
+var captureClashingWithNamespace = lx.Exp(
+	lx.Capture("start_year", lx.Digit.Exactly(4)),
+	lx.Namespace("start", lx.Capture("year", lx.Digit.Exactly(4))), // want `duplicate capture name "start_year" in this Exp`
+)
+
+func unknownNamespace(prefix string) lx.ExpTreeNode {
+	return lx.Exp(
+		lx.Capture("year", lx.Digit.Exactly(4)),
+		lx.Namespace(prefix, lx.Capture("year", lx.Digit.Exactly(4))),
+	)
+}
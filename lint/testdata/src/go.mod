@@ -0,0 +1,7 @@
+module lintdata
+
+go 1.21.4
+
+require lirex v0.0.0
+
+replace lirex => ../../..
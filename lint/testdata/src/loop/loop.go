@@ -0,0 +1,52 @@
+package loop
+
+import (
+	"regexp"
+
+	lx "lirex/lirex"
+)
+
+var exp = lx.Exp(lx.Digit.AtLeast(1))
+
+func inLoop(n int) {
+	for i := 0; i < n; i++ {
+		exp.MustCompile(lx.Options{}) // want `MustCompile inside a loop \(line 12\) compiles on every iteration`
+	}
+}
+
+func inRange(opts []lx.Options) {
+	for _, o := range opts {
+		if true {
+			exp.MustCompile(o) // want `MustCompile inside a loop \(line 18\) compiles on every iteration`
+		}
+	}
+}
+
+func outsideLoop() *regexp.Regexp {
+	return exp.MustCompile(lx.Options{})
+}
+
+// The func literal may run once, after the loop is done.
+func inFuncLit(n int) []func() *regexp.Regexp {
+	compilers := []func() *regexp.Regexp{}
+	for i := 0; i < n; i++ {
+		compilers = append(compilers, func() *regexp.Regexp { return exp.MustCompile(lx.Options{}) })
+	}
+	return compilers
+}
+
+func loopInFuncLit() func(int) {
+	return func(n int) {
+		for i := 0; i < n; i++ {
+			exp.MustCompile(lx.Options{}) // want `MustCompile inside a loop \(line 40\) compiles on every iteration`
+		}
+	}
+}
+
+var all []*regexp.Regexp
+
+func init() {
+	for _, opts := range []lx.Options{{}, {CaseInsensitive: true}} {
+		all = append(all, exp.MustCompile(opts))
+	}
+}
@@ -0,0 +1,11 @@
+package unsaferaw
+
+import lx "lirex/lirex"
+
+var (
+	plain   = lx.UnsafeRaw("abc")   // want `UnsafeRaw\("abc"\) only matches the text "abc"; use Lit\("abc"\)`
+	escaped = lx.UnsafeRaw(`v1\.0`) // want `UnsafeRaw\("v1\\\\.0"\) only matches the text "v1.0"; use Lit\("v1.0"\)`
+	class   = lx.UnsafeRaw(`[a-z]+`)
+	folded  = lx.UnsafeRaw(`(?i)abc`)
+	broken  = lx.UnsafeRaw(`(abc`)
+)
@@ -0,0 +1,53 @@
+package lirex
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// ANCHORED -----------------------------------------------------------------------------
+// A regex matched at a position of a longer string. Matching the slice from that position would
+// hide the text before it, so \b, \B and ^ would see a start of text that isn't there; here the
+// previous rune is matched along and then dropped.
+type AnchoredRegexp struct {
+	// \A(?:re), used at the start of the string
+	atStart *regexp.Regexp
+	// \A(?s:.)(?:re), the dot consumes the rune before the position
+	inside *regexp.Regexp
+}
+
+// Wraps re for matching at given positions with FindAt, as the lexer does for each token and
+// lirex/peg for each terminal.
+func Anchor(re *regexp.Regexp) *AnchoredRegexp {
+	return &AnchoredRegexp{
+		atStart: regexp.MustCompile(`\A(?:` + re.String() + `)`),
+		inside:  regexp.MustCompile(`\A(?s:.)(?:` + re.String() + `)`),
+	}
+}
+
+// Switches to leftmost-longest matching, see regexp.Regexp.Longest.
+func (a *AnchoredRegexp) Longest() {
+	a.atStart.Longest()
+	a.inside.Longest()
+}
+
+// Submatch index pairs of the match starting at pos, as offsets into str, or nil. The match
+// ends at or before end; text after end is not seen.
+func (a *AnchoredRegexp) FindAt(str string, pos, end int) []int {
+	if pos == 0 {
+		return a.atStart.FindStringSubmatchIndex(str[:end])
+	}
+	_, size := utf8.DecodeLastRuneInString(str[:pos])
+	base := pos - size
+	loc := a.inside.FindStringSubmatchIndex(str[base:end])
+	if loc == nil {
+		return nil
+	}
+	for i := range loc {
+		if loc[i] >= 0 {
+			loc[i] += base
+		}
+	}
+	loc[0] = pos
+	return loc
+}
@@ -0,0 +1,67 @@
+package lirex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ANY ORDER ----------------------------------------------------------------------------
+type AnyOrderNode struct {
+	children []Node
+}
+
+// Beyond this the n! permutations make the pattern too large to compile.
+const maxAnyOrderChildren = 6
+
+// Every child exactly once, in any order.
+// Regex equivalent (a, b, c): (?:a(?:bc|cb)|b(?:ac|ca)|c(?:ab|ba))
+func AnyOrder(nodes ...Node) AnyOrderNode {
+	return AnyOrderNode{children: nodes}
+}
+
+// Children are compiled once, so each capture is registered once and then repeated
+// under the same name in every permutation; FindCaptures and FindMatch merge them.
+func (node AnyOrderNode) compile(ctx *CompileContext) (string, error) {
+	if len(node.children) > maxAnyOrderChildren {
+		return "", fmt.Errorf("Lirex Compile: AnyOrder() accepts at most %d children, got %d.", maxAnyOrderChildren, len(node.children))
+	}
+	compiled := []string{}
+	for _, child := range node.children {
+		c, err := child.compile(ctx)
+		if err != nil {
+			return "", err
+		}
+		if c != "" {
+			compiled = append(compiled, "(?:"+c+")")
+		}
+	}
+	if len(compiled) == 0 {
+		return "", handleEmptyNode(node, ctx)
+	}
+	if len(compiled) == 1 {
+		if ctx.showWarnings {
+			fmt.Println("WARNING: AnyOrder() with only one child => Could use the child directly.")
+		}
+		return compiled[0], nil
+	}
+	return "(?:" + permutations(compiled) + ")", nil
+}
+
+// Alternation of all orderings, factored on the first element of each.
+func permutations(parts []string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	alternatives := make([]string, len(parts))
+	for i, first := range parts {
+		rest := make([]string, 0, len(parts)-1)
+		rest = append(rest, parts[:i]...)
+		rest = append(rest, parts[i+1:]...)
+		if len(rest) == 1 {
+			alternatives[i] = first + rest[0]
+		} else {
+			alternatives[i] = first + "(?:" + permutations(rest) + ")"
+		}
+	}
+	return strings.Join(alternatives, "|")
+}
@@ -1,5 +1,11 @@
 package lirex
 
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
 type Node interface {
 	compile(*CompileContext) (string, error)
 	explain() string
@@ -19,6 +25,9 @@ func (RuneCharNode) repeatableNode()  {}
 func (GroupNode) repeatableNode()     {}
 func (OrNode) repeatableNode()        {}
 func (CharClassNode) repeatableNode() {}
+func (RuleNode) repeatableNode()      {}
+func (RefNode) repeatableNode()       {}
+func (AnyOrderNode) repeatableNode()  {}
 
 func (LitNode) charClassableNode()      {}
 func (RuneCharNode) charClassableNode() {}
@@ -86,6 +95,21 @@ func NotCharClass(nodes ...CharClassable) CharClassNode {
 	return CharClassNode{children: nodes, negate: true}
 }
 
+// Regex equivalent: [from-to]
+func CharRange(from, to rune) RuneCharNode {
+	return RuneCharNode{value: "[" + classRune(from) + "-" + classRune(to) + "]"}
+}
+
+func classRune(r rune) string {
+	if strings.ContainsRune(`[]-\/^`, r) {
+		return `\` + string(r)
+	}
+	if !unicode.IsPrint(r) {
+		return fmt.Sprintf(`\x{%x}`, r)
+	}
+	return string(r)
+}
+
 // REPEAT ---------------------------------------------------------------------------------
 type AtLeastRepeatNode struct {
 	child Repeatable
@@ -124,6 +148,9 @@ func (node RuneCharNode) AtLeast(n uint) AtLeastRepeatNode  { return atLeast(nod
 func (node GroupNode) AtLeast(n uint) AtLeastRepeatNode     { return atLeast(node, n) }
 func (node OrNode) AtLeast(n uint) AtLeastRepeatNode        { return atLeast(node, n) }
 func (node CharClassNode) AtLeast(n uint) AtLeastRepeatNode { return atLeast(node, n) }
+func (node RuleNode) AtLeast(n uint) AtLeastRepeatNode      { return atLeast(node, n) }
+func (node RefNode) AtLeast(n uint) AtLeastRepeatNode       { return atLeast(node, n) }
+func (node AnyOrderNode) AtLeast(n uint) AtLeastRepeatNode  { return atLeast(node, n) }
 
 // Regex equivalent: ...*
 func (node LitNode) ZeroOrMore() AtLeastRepeatNode       { return atLeast(node, 0) }
@@ -132,6 +159,9 @@ func (node RuneCharNode) ZeroOrMore() AtLeastRepeatNode  { return atLeast(node,
 func (node GroupNode) ZeroOrMore() AtLeastRepeatNode     { return atLeast(node, 0) }
 func (node OrNode) ZeroOrMore() AtLeastRepeatNode        { return atLeast(node, 0) }
 func (node CharClassNode) ZeroOrMore() AtLeastRepeatNode { return atLeast(node, 0) }
+func (node RuleNode) ZeroOrMore() AtLeastRepeatNode      { return atLeast(node, 0) }
+func (node RefNode) ZeroOrMore() AtLeastRepeatNode       { return atLeast(node, 0) }
+func (node AnyOrderNode) ZeroOrMore() AtLeastRepeatNode  { return atLeast(node, 0) }
 
 // Regex equivalent: ...{n}
 func (node LitNode) Exactly(n uint) ExactlyRepeatNode       { return exactly(node, n) }
@@ -140,6 +170,9 @@ func (node RuneCharNode) Exactly(n uint) ExactlyRepeatNode  { return exactly(nod
 func (node GroupNode) Exactly(n uint) ExactlyRepeatNode     { return exactly(node, n) }
 func (node OrNode) Exactly(n uint) ExactlyRepeatNode        { return exactly(node, n) }
 func (node CharClassNode) Exactly(n uint) ExactlyRepeatNode { return exactly(node, n) }
+func (node RuleNode) Exactly(n uint) ExactlyRepeatNode      { return exactly(node, n) }
+func (node RefNode) Exactly(n uint) ExactlyRepeatNode       { return exactly(node, n) }
+func (node AnyOrderNode) Exactly(n uint) ExactlyRepeatNode  { return exactly(node, n) }
 
 // Regex equivalent: ...{n,m} || ...?
 func (node LitNode) Between(from, to uint) BetweenRepeatNode       { return between(node, from, to) }
@@ -148,6 +181,9 @@ func (node RuneCharNode) Between(from, to uint) BetweenRepeatNode  { return betw
 func (node GroupNode) Between(from, to uint) BetweenRepeatNode     { return between(node, from, to) }
 func (node OrNode) Between(from, to uint) BetweenRepeatNode        { return between(node, from, to) }
 func (node CharClassNode) Between(from, to uint) BetweenRepeatNode { return between(node, from, to) }
+func (node RuleNode) Between(from, to uint) BetweenRepeatNode      { return between(node, from, to) }
+func (node RefNode) Between(from, to uint) BetweenRepeatNode       { return between(node, from, to) }
+func (node AnyOrderNode) Between(from, to uint) BetweenRepeatNode  { return between(node, from, to) }
 
 // Regex equivalent: ...?
 func (node LitNode) Optional() OptionalRepeatNode       { return optional(node) }
@@ -156,3 +192,6 @@ func (node RuneCharNode) Optional() OptionalRepeatNode  { return optional(node)
 func (node GroupNode) Optional() OptionalRepeatNode     { return optional(node) }
 func (node OrNode) Optional() OptionalRepeatNode        { return optional(node) }
 func (node CharClassNode) Optional() OptionalRepeatNode { return optional(node) }
+func (node RuleNode) Optional() OptionalRepeatNode      { return optional(node) }
+func (node RefNode) Optional() OptionalRepeatNode       { return optional(node) }
+func (node AnyOrderNode) Optional() OptionalRepeatNode  { return optional(node) }
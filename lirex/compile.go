@@ -73,18 +73,26 @@ func toRegularNodes[T Node](irrNodes []T) []Node {
 }
 
 func (node HelperNode) compile(ctx *CompileContext) (string, error) {
-	if _, exists := ctx.helpersUsed[node.name]; exists {
-		return "", fmt.Errorf("Lirex Compile: Helper '%s' used more than once.", node.name)
+	if node.err != nil {
+		return "", node.err
 	}
-	ctx.helpersUsed[node.name] = struct{}{}
+	name := ctx.qualify(node.name)
+	if _, exists := ctx.helpersUsed[name]; !ctx.grammar.take("helper:"+name, exists) {
+		return "", fmt.Errorf("Lirex Compile: Helper '%s' used more than once.", name)
+	}
+	ctx.helpersUsed[name] = struct{}{}
 	for _, groupName := range node.groupNames {
-		if _, exists := ctx.groupNames[groupName]; exists {
+		groupName = ctx.qualify(groupName)
+		if _, exists := ctx.groupNames[groupName]; !ctx.grammar.take(groupName, exists) {
 			return "", fmt.Errorf("Lirex Compile: Capture group name '%s' is reserved by Lirex. Use another name.", groupName)
 		}
 		ctx.groupNames[groupName] = struct{}{}
 	}
 
-	return node.value, nil
+	if len(ctx.namespace) == 0 {
+		return node.value, nil
+	}
+	return renameCaptures(node.value, ctx.qualify)
 }
 func (n MetaCharNode) compile(*CompileContext) (string, error) { return n.value, nil }
 func (n RuneCharNode) compile(*CompileContext) (string, error) { return n.value, nil }
@@ -116,23 +124,28 @@ func (node RawNode) compile(ctx *CompileContext) (string, error) {
 	return val, err
 }
 
+// A Latin letter, then word characters. A function rather than a compiled Exp so that package
+// variables built with Capture() don't depend on initialization order.
+func validCaptureName(name string) bool {
+	for i, r := range name {
+		letter := 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+		if !letter && (i == 0 || r != '_' && (r < '0' || r > '9')) {
+			return false
+		}
+	}
+	return name != ""
+}
+
 func (node CaptureNode) compile(ctx *CompileContext) (string, error) {
 	children := node.children
 	if len(children) == 0 {
 		return "", fmt.Errorf("Lirex Compile: Capture() must have have children. Instead Capture (name=%s) has 0 children.", node.name)
 	}
-	var captureNameExp = Exp(
-		LineStart,
-		Latin,
-		WordChar.ZeroOrMore(),
-		LineEnd,
-	).MustCompile(Options{})
-
-	name := node.name
-	if !captureNameExp.MatchString(name) {
-		return "", fmt.Errorf("Lirex Compile: Capture: invalid name for capture group '%s'.", name)
+	if !validCaptureName(node.name) {
+		return "", fmt.Errorf("Lirex Compile: Capture: invalid name for capture group '%s'.", node.name)
 	}
-	if _, exists := ctx.groupNames[name]; exists {
+	name := ctx.qualify(node.name)
+	if _, exists := ctx.groupNames[name]; !ctx.grammar.take(name, exists) {
 		hint := ""
 		if _, exists := ReservedGroupNames[name]; exists {
 			hint = "\nHint: This name is reserved by Lirex. Use another one."
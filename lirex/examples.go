@@ -0,0 +1,89 @@
+package lirex
+
+import (
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// Generates up to n distinct strings fully matched by pattern. Candidates are built by walking the
+// parsed pattern with a seeded random source and are kept only if the pattern really matches them.
+func generateExamples(pattern string, n int) []string {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	full, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil
+	}
+
+	examples := []string{}
+	seen := map[string]struct{}{}
+	for seed := int64(0); seed < int64(n)*10 && len(examples) < n; seed++ {
+		var b strings.Builder
+		generateExample(parsed, rand.New(rand.NewSource(seed)), &b)
+		example := b.String()
+		if _, exists := seen[example]; exists || !full.MatchString(example) {
+			continue
+		}
+		seen[example] = struct{}{}
+		examples = append(examples, example)
+	}
+	return examples
+}
+
+func generateExample(re *syntax.Regexp, r *rand.Rand, b *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(pickRune(re.Rune, r))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(rune('a' + r.Intn(26)))
+	case syntax.OpCapture:
+		generateExample(re.Sub[0], r, b)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			generateExample(sub, r, b)
+		}
+	case syntax.OpAlternate:
+		generateExample(re.Sub[r.Intn(len(re.Sub))], r, b)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, 2
+		case syntax.OpPlus:
+			min, max = 1, 3
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 || max > min+2 {
+			max = min + 2
+		}
+		for i := min + r.Intn(max-min+1); i > 0; i-- {
+			generateExample(re.Sub[0], r, b)
+		}
+	}
+}
+
+// Prefers printable ASCII so examples stay readable.
+func pickRune(ranges []rune, r *rand.Rand) rune {
+	printable := []rune{}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := max(ranges[i], ' '), min(ranges[i+1], '~')
+		if lo <= hi {
+			printable = append(printable, lo, hi)
+		}
+	}
+	if len(printable) > 0 {
+		ranges = printable
+	}
+	if len(ranges) == 0 {
+		return 'a'
+	}
+	i := r.Intn(len(ranges)/2) * 2
+	return ranges[i] + rune(r.Intn(int(ranges[i+1]-ranges[i])+1))
+}
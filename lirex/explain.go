@@ -1,58 +1,412 @@
 package lirex
 
-type ExplainedNode struct {
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EXPLAIN ------------------------------------------------------------------------------
+// Structured explanation of an expression: the node tree, what it matches in plain English
+// and the regex it compiles to.
+//
+//	Seq
+//	  LineStart: the start of the line
+//	  Capture("user")
+//	    AtLeast(1)
+//	      WordChar: a word character
+//	  ...
+func (tree ExpTreeNode) Explanation(opts Options) string {
+	var b strings.Builder
+	b.WriteString(tree.Tree())
+	b.WriteString("\nMatches: " + tree.Describe() + "\n")
+	re, err := tree.Compile(opts)
+	if err != nil {
+		b.WriteString("Error: " + err.Error() + "\n")
+		return b.String()
+	}
+	b.WriteString("Regex: " + re.String() + "\n")
+	return b.String()
+}
+
+// Prints Explanation(opts) to stdout.
+func (tree ExpTreeNode) Explain(opts Options) {
+	fmt.Print(tree.Explanation(opts))
+}
+
+// What the expression matches, as one English sentence.
+func (tree ExpTreeNode) Describe() string {
+	description := Seq(tree...).explain()
+	if description == "" {
+		return "Nothing."
+	}
+	return strings.ToUpper(description[:1]) + description[1:] + "."
+}
+
+// One line per node, indented by depth, with what each node matches.
+func (tree ExpTreeNode) Tree() string {
+	var b strings.Builder
+	ctx := &ExplainContext{}
+	for _, node := range tree {
+		writeTree(&b, node, ctx)
+	}
+	return b.String()
+}
+
+func writeTree(b *strings.Builder, node Node, ctx *ExplainContext) {
+	b.WriteString(strings.Repeat("  ", int(ctx.indent)) + nodeLabel(node))
+	children := explainChildren(node)
+	if len(children) == 0 {
+		b.WriteString(": " + node.explain())
+	}
+	b.WriteString("\n")
+	ctx.indent++
+	for _, child := range children {
+		writeTree(b, child, ctx)
+	}
+	ctx.indent--
+}
+
+// Children shown under a node in Tree(): its childNodes plus the items of char classes.
+func explainChildren(node Node) []Node {
+	if class, ok := node.(CharClassNode); ok {
+		children := make([]Node, len(class.children))
+		for i, child := range class.children {
+			children[i] = child
+		}
+		return children
+	}
+	return childNodes(node)
+}
+
+// Short name of a node, close to the call that builds it.
+func nodeLabel(node Node) string {
+	switch node := node.(type) {
+	case SeqNode:
+		return "Seq"
+	case LitNode:
+		return "Lit(" + strconv.Quote(node.value) + ")"
+	case RawNode:
+		return "UnsafeRaw(" + strconv.Quote(node.value) + ")"
+	case MetaCharNode:
+		if name, ok := runeCharName(node.value); ok {
+			return name
+		}
+		return "MetaChar(" + strconv.Quote(node.value) + ")"
+	case RuneCharNode:
+		if name, ok := runeCharName(node.value); ok {
+			return name
+		}
+		if from, to, ok := charRangeBounds(node.value); ok {
+			return fmt.Sprintf("CharRange(%q, %q)", from, to)
+		}
+		return "RuneChar(" + strconv.Quote(node.value) + ")"
+	case GroupNode:
+		return "Group"
+	case CaptureNode:
+		return "Capture(" + strconv.Quote(node.name) + ")"
+	case OrNode:
+		return "Or"
+	case CharClassNode:
+		if node.negate {
+			return "NotCharClass"
+		}
+		return "CharClass"
+	case AtLeastRepeatNode:
+		if node.num == 0 {
+			return "ZeroOrMore"
+		}
+		return fmt.Sprintf("AtLeast(%d)", node.num)
+	case ExactlyRepeatNode:
+		return fmt.Sprintf("Exactly(%d)", node.num)
+	case BetweenRepeatNode:
+		return fmt.Sprintf("Between(%d, %d)", node.min, node.max)
+	case OptionalRepeatNode:
+		return "Optional"
+	case HelperNode:
+		if field, ok := helperField(node); ok {
+			return "Helpers." + field
+		}
+		return "Helper(" + strconv.Quote(node.name) + ")"
+	case TimeLayoutNode:
+		return "TimeLayout(" + strconv.Quote(node.layout) + ")"
+	case RuleNode:
+		return "Rule(" + strconv.Quote(node.name) + ")"
+	case RefNode:
+		return "Ref(" + strconv.Quote(node.name) + ")"
+	case NamespaceNode:
+		return "Namespace(" + strconv.Quote(node.prefix) + ")"
+	case ListNode:
+		return fmt.Sprintf("List(%d, %d)", node.min, node.max)
+	case QuotedNode:
+		return fmt.Sprintf("Quoted(%q, %q)", node.open, node.close)
+	case AnyOrderNode:
+		return "AnyOrder"
+	case NumberNode:
+		return [...]string{"Integer", "Decimal", "Float"}[node.kind]
+	case RouteNode:
+		return "Route(" + strconv.Quote(node.template) + ")"
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+// Name of the Helpers field holding node.
+func helperField(node HelperNode) (string, bool) {
+	for _, name := range HelperNames() {
+		if helper, _ := LookupHelper(name); helper.value == node.value && helper.name == node.name {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// RUNES --------------------------------------------------------------------------------
+type runeChar struct {
+	value       string
+	name        string
+	description string
+}
+
+var runeChars = []runeChar{
+	{`\s`, "Whitespace", "a whitespace character"},
+	{`\S`, "NonWhitespace", "a non-whitespace character"},
+	{`\t`, "Tab", "a tab"},
+	{`\n`, "Newline", "a newline"},
+	{`(\r\n|\n|\r)`, "LineBreak", "a line break"},
+	{`[a-z]`, "LowerLatin", "a lowercase letter a-z"},
+	{`[A-Z]`, "UpperLatin", "an uppercase letter A-Z"},
+	{`[a-zA-Z]`, "Latin", "a letter a-z or A-Z"},
+	{`[a-zA-Z0-9]`, "LatinDigit", "a letter or digit"},
+	{`\p{Latin}`, "ExtendedLatin", "a Latin-script letter"},
+	{`\p{L}`, "Letter", "a letter"},
+	{`\p{Lu}`, "UpperLetter", "an uppercase letter"},
+	{`\p{Ll}`, "LowerLetter", "a lowercase letter"},
+	{`\p{Cyrillic}`, "Cyrillic", "a Cyrillic letter"},
+	{`\p{Greek}`, "Greek", "a Greek letter"},
+	{`\p{Arabic}`, "Arabic", "an Arabic letter"},
+	{`\p{Hebrew}`, "Hebrew", "a Hebrew letter"},
+	{`\p{Han}`, "Han", "a Han character"},
+	{`\p{Nd}`, "AnyDecimal", "a decimal digit in any script"},
+	{`\p{N}`, "NumberLike", "a numeric character"},
+	{`[0-9A-Fa-f]`, "HexDigit", "a hexadecimal digit"},
+	{`\d`, "Digit", "a digit"},
+	{`\D`, "NonDigit", "a non-digit"},
+	{`\p{P}`, "Punctuation", "a punctuation character"},
+	{`\p{S}`, "Symbol", "a symbol"},
+	{`\w`, "WordChar", "a word character"},
+	{`\W`, "NonWordChar", "a non-word character"},
+	{`.`, "AnyChar", "any character"},
+	{`$`, "LineEnd", "the end of the line"},
+	{`^`, "LineStart", "the start of the line"},
+	{`\r`, "Return", "a carriage return"},
+	{`\b`, "WordBoundary", "a word boundary"},
+	{`\B`, "NonWordBoundary", "a position that is not a word boundary"},
+}
+
+func lookupRuneChar(value string) (runeChar, bool) {
+	for _, r := range runeChars {
+		if r.value == value {
+			return r, true
+		}
+	}
+	return runeChar{}, false
+}
+
+func runeCharName(value string) (string, bool) {
+	r, ok := lookupRuneChar(value)
+	return r.name, ok
+}
+
+// Bounds of a CharRange() value such as [a-f].
+func charRangeBounds(value string) (rune, rune, bool) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return 0, 0, false
+	}
+	from, rest, ok := unquoteClassRune(value[1 : len(value)-1])
+	if !ok || !strings.HasPrefix(rest, "-") {
+		return 0, 0, false
+	}
+	to, rest, ok := unquoteClassRune(rest[1:])
+	if !ok || rest != "" {
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// Reverses classRune for the first rune of s.
+func unquoteClassRune(s string) (rune, string, bool) {
+	switch {
+	case s == "":
+		return 0, "", false
+	case strings.HasPrefix(s, `\x{`):
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return 0, "", false
+		}
+		r, err := strconv.ParseUint(s[3:end], 16, 32)
+		return rune(r), s[end+1:], err == nil
+	case s[0] == '\\' && len(s) > 1:
+		return rune(s[1]), s[2:], strings.ContainsRune(`[]-\/^`, rune(s[1]))
+	}
+	r := []rune(s)[0]
+	return r, s[len(string(r)):], r != '\\'
+}
+
+// NODES --------------------------------------------------------------------------------
+func explainNodes(nodes []Node, sep string) string {
+	parts := []string{}
+	for _, node := range nodes {
+		if part := node.explain(); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, sep)
 }
 
 func (n SeqNode) explain() string {
-	return ""
+	return explainNodes(n.nodes, ", then ")
 }
 func (n HelperNode) explain() string {
-	return ""
+	if n.description == "" {
+		return withArticle(n.name)
+	}
+	return withArticle(n.description)
+}
+
+// Prefixes s with "a" or "an" by how its first word is spoken: "an IBAN", "a UUID", "an RFC".
+// Capitalized words without vowels are read letter by letter.
+func withArticle(s string) string {
+	word := s
+	if end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+		word = s[:end]
+	}
+	if word == "" {
+		return "a " + s
+	}
+	vowelSound := strings.ContainsRune("aeiouAEIO", rune(word[0]))
+	if strings.ToUpper(word) == word && !strings.ContainsAny(word, "AEIOU") {
+		vowelSound = strings.ContainsRune("FHLMNRSX", rune(word[0]))
+	}
+	if vowelSound {
+		return "an " + s
+	}
+	return "a " + s
 }
 
 func (node LitNode) explain() string {
-	return ""
+	return "the text " + strconv.Quote(node.value)
 }
 func (n MetaCharNode) explain() string {
-	return ""
-
+	if r, ok := lookupRuneChar(n.value); ok {
+		return r.description
+	}
+	return "the regex " + n.value
 }
 func (n RuneCharNode) explain() string {
-	return ""
-
+	if r, ok := lookupRuneChar(n.value); ok {
+		return r.description
+	}
+	if from, to, ok := charRangeBounds(n.value); ok {
+		return fmt.Sprintf("a character from %q to %q", from, to)
+	}
+	return "a character matching " + n.value
 }
 func (n RawNode) explain() string {
-	return ""
-
+	return "the raw regex " + n.value
 }
 
 func (node GroupNode) explain() string {
-	return ""
-
+	return explainNodes(node.children, ", then ")
 }
 
 func (node CaptureNode) explain() string {
-	return ""
-
+	return fmt.Sprintf("%s (captured as %q)", explainNodes(node.children, ", then "), node.name)
 }
 
 func (node OrNode) explain() string {
-	return ""
+	return "either " + explainNodes(node.children, ", or ")
 }
 
 func (node CharClassNode) explain() string {
-	return ""
+	items := []string{}
+	for _, child := range node.children {
+		if lit, ok := child.(LitNode); ok {
+			for _, r := range lit.value {
+				items = append(items, strconv.QuoteRune(r))
+			}
+			continue
+		}
+		items = append(items, child.explain())
+	}
+	if node.negate {
+		return "a character other than " + strings.Join(items, ", ")
+	}
+	return "one of " + strings.Join(items, ", ")
 }
 func (node AtLeastRepeatNode) explain() string {
-	return ""
-
+	switch node.num {
+	case 0:
+		return "zero or more of " + node.child.explain()
+	case 1:
+		return "one or more of " + node.child.explain()
+	}
+	return fmt.Sprintf("%d or more of %s", node.num, node.child.explain())
 }
 func (node ExactlyRepeatNode) explain() string {
-	return ""
+	return fmt.Sprintf("exactly %d of %s", node.num, node.child.explain())
 }
 func (node BetweenRepeatNode) explain() string {
-	return ""
+	return fmt.Sprintf("%d to %d of %s", node.min, node.max, node.child.explain())
 }
 func (node OptionalRepeatNode) explain() string {
-	return ""
+	return "optionally " + node.child.explain()
+}
+func (node TimeLayoutNode) explain() string {
+	return "a time formatted as " + strconv.Quote(node.layout)
+}
+func (node RuleNode) explain() string {
+	return fmt.Sprintf("%s (rule %q)", explainNodes(node.children, ", then "), node.name)
+}
+func (node RefNode) explain() string {
+	return fmt.Sprintf("whatever rule %q matches", node.name)
+}
+func (node NamespaceNode) explain() string {
+	return fmt.Sprintf("%s (captures prefixed with %q)", explainNodes(node.children, ", then "), node.prefix+"_")
+}
+func (node ListNode) explain() string {
+	count := fmt.Sprintf("%d to %d", node.min, node.max)
+	if node.max == 0 {
+		count = fmt.Sprintf("%d or more", node.min)
+	}
+	return fmt.Sprintf("a list of %s items, each %s, separated by %s", count, node.item.explain(), node.sep.explain())
+}
+func (node QuotedNode) explain() string {
+	description := fmt.Sprintf("text between %q and %q", node.open, node.close)
+	if node.opts.Escape != 0 {
+		description += fmt.Sprintf(" with %q escapes", node.opts.Escape)
+	}
+	if node.opts.Capture != "" {
+		description += fmt.Sprintf(" (captured as %q)", node.opts.Capture)
+	}
+	return description
+}
+func (node AnyOrderNode) explain() string {
+	return "all of " + explainNodes(node.children, ", ") + " in any order"
+}
+func (node NumberNode) explain() string {
+	description := [...]string{"an integer", "a decimal number", "a floating-point number"}[node.kind]
+	if node.opts.ThousandsSep != 0 {
+		description += fmt.Sprintf(" with %q between digit groups", node.opts.ThousandsSep)
+	}
+	if len(node.opts.Currency) > 0 {
+		description += " and a currency symbol (" + strings.Join(node.opts.Currency, ", ") + ")"
+	}
+	if node.opts.Capture != "" {
+		description += fmt.Sprintf(" (captured as %q)", node.opts.Capture)
+	}
+	return description
+}
+func (node RouteNode) explain() string {
+	return "a path like " + strconv.Quote(node.template)
 }
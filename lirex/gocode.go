@@ -0,0 +1,168 @@
+package lirex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GO CODE ------------------------------------------------------------------------------
+// Go source that builds the expression, with pkg as the package qualifier ("" for none).
+// Covers the nodes Parse() produces plus helpers and repeats; other nodes return an error.
+//
+//	Exp(LineStart, Capture("user", WordChar.AtLeast(1))).GoCode("lx")
+//	=> lx.Exp(\n\tlx.LineStart,\n\tlx.Capture("user", lx.WordChar.AtLeast(1)),\n)
+func (tree ExpTreeNode) GoCode(pkg string) (string, error) {
+	g := goWriter{pkg: pkg}
+	var b strings.Builder
+	b.WriteString(g.name("Exp") + "(\n")
+	for _, node := range tree {
+		code, err := g.node(node)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("\t" + code + ",\n")
+	}
+	b.WriteString(")")
+	return b.String(), nil
+}
+
+// Go source of the Options literal, e.g. lx.Options{CaseInsensitive: true}.
+func (opts Options) GoCode(pkg string) string {
+	fields := []string{}
+	if opts.CaseInsensitive {
+		fields = append(fields, "CaseInsensitive: true")
+	}
+	if opts.Multiline {
+		fields = append(fields, "Multiline: true")
+	}
+	if opts.DotMatchesNewline {
+		fields = append(fields, "DotMatchesNewline: true")
+	}
+	if opts.ShowWarnings {
+		fields = append(fields, "ShowWarnings: true")
+	}
+	if opts.AllowRedundant {
+		fields = append(fields, "AllowRedundant: true")
+	}
+	if opts.MaxRecursion != 0 {
+		fields = append(fields, fmt.Sprintf("MaxRecursion: %d", opts.MaxRecursion))
+	}
+	return goWriter{pkg: pkg}.name("Options") + "{" + strings.Join(fields, ", ") + "}"
+}
+
+type goWriter struct {
+	pkg string
+}
+
+func (g goWriter) name(name string) string {
+	if g.pkg == "" {
+		return name
+	}
+	return g.pkg + "." + name
+}
+
+func (g goWriter) nodes(nodes []Node) (string, error) {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		code, err := g.node(node)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = code
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+func (g goWriter) call(name string, nodes []Node, args ...string) (string, error) {
+	children, err := g.nodes(nodes)
+	if err != nil {
+		return "", err
+	}
+	if children != "" {
+		args = append(args, children)
+	}
+	return g.name(name) + "(" + strings.Join(args, ", ") + ")", nil
+}
+
+func (g goWriter) node(node Node) (string, error) {
+	switch node := node.(type) {
+	case SeqNode:
+		return g.call("Seq", node.nodes)
+	case LitNode:
+		return g.name("Lit") + "(" + goString(node.value) + ")", nil
+	case RawNode:
+		return g.name("UnsafeRaw") + "(" + goString(node.value) + ")", nil
+	case MetaCharNode:
+		if name, ok := runeCharName(node.value); ok {
+			return g.name(name), nil
+		}
+	case RuneCharNode:
+		if name, ok := runeCharName(node.value); ok {
+			return g.name(name), nil
+		}
+		if from, to, ok := charRangeBounds(node.value); ok {
+			return g.name("CharRange") + fmt.Sprintf("(%q, %q)", from, to), nil
+		}
+	case GroupNode:
+		return g.call("Group", node.children)
+	case CaptureNode:
+		return g.call("Capture", node.children, strconv.Quote(node.name))
+	case OrNode:
+		return g.call("Or", node.children)
+	case CharClassNode:
+		name := "CharClass"
+		if node.negate {
+			name = "NotCharClass"
+		}
+		children := make([]Node, len(node.children))
+		for i, child := range node.children {
+			children[i] = child
+		}
+		return g.call(name, children)
+	case AtLeastRepeatNode:
+		if node.num == 0 {
+			return g.method(node.child, "ZeroOrMore()")
+		}
+		return g.method(node.child, fmt.Sprintf("AtLeast(%d)", node.num))
+	case ExactlyRepeatNode:
+		return g.method(node.child, fmt.Sprintf("Exactly(%d)", node.num))
+	case BetweenRepeatNode:
+		return g.method(node.child, fmt.Sprintf("Between(%d, %d)", node.min, node.max))
+	case OptionalRepeatNode:
+		return g.method(node.child, "Optional()")
+	case HelperNode:
+		if field, ok := helperField(node); ok {
+			return g.name("Helpers") + "." + field, nil
+		}
+	case TimeLayoutNode:
+		return g.name("TimeLayout") + "(" + strconv.Quote(node.layout) + ")", nil
+	case RuleNode:
+		return g.call("Rule", node.children, strconv.Quote(node.name))
+	case RefNode:
+		return g.name("Ref") + "(" + strconv.Quote(node.name) + ")", nil
+	case NamespaceNode:
+		return g.call("Namespace", node.children, strconv.Quote(node.prefix))
+	case RouteNode:
+		return g.name("Route") + "(" + strconv.Quote(node.template) + ")", nil
+	}
+	return "", fmt.Errorf("Lirex GoCode: no Go source for %s.", nodeLabel(node))
+}
+
+// child.repeat, e.g. lx.Digit.AtLeast(1)
+func (g goWriter) method(child Repeatable, repeat string) (string, error) {
+	code, err := g.node(child)
+	if err != nil {
+		return "", err
+	}
+	return code + "." + repeat, nil
+}
+
+// Raw string literal when it reads better, e.g. `\d+` over "\\d+".
+func goString(s string) string {
+	quoted := strconv.Quote(s)
+	if strings.Contains(quoted, `\\`) && !strings.ContainsAny(s, "`\r") && strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return quoted
+}
@@ -0,0 +1,202 @@
+package lirex
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RULE ---------------------------------------------------------------------------------
+type RuleNode struct {
+	name     string
+	children []Node
+}
+type RefNode struct {
+	name string
+}
+
+// Defines a named rule and matches it in place. Other parts of the expression (including the
+// rule itself) can reuse it with Ref(name).
+func Rule(name string, nodes ...Node) RuleNode {
+	return RuleNode{name: name, children: nodes}
+}
+
+// Matches the rule defined with Rule(name) anywhere in the same expression. Recursive rules are
+// expanded up to Options.MaxRecursion levels; deeper references never match. Every expansion
+// copies the rule, so a rule referencing itself twice doubles per level: expansions over 1 MiB
+// are compile errors. Captures and helpers inside a rule repeat under the same
+// name in each expansion; FindCaptures and FindMatch merge them.
+func Ref(name string) RefNode {
+	return RefNode{name: name}
+}
+
+// Matches nothing, used where a recursive rule runs out of depth.
+const neverMatch = `[^\x00-\x{10FFFF}]`
+
+// Largest regex one rule expansion may produce.
+const maxRuleSize = 1 << 20
+
+type grammar struct {
+	rules map[string]RuleNode
+	// Rules reachable from themselves through Ref
+	recursive map[string]bool
+	// Active expansions per rule
+	depth map[string]uint
+	// Rules being expanded, innermost last
+	expanding []string
+	// Rule whose expansion first took a group or helper name
+	owners map[string]string
+}
+
+func (node RuleNode) compile(ctx *CompileContext) (string, error) {
+	return expandRule(node, ctx)
+}
+func (node RefNode) compile(ctx *CompileContext) (string, error) {
+	rule, exists := ctx.grammar.rules[node.name]
+	if !exists {
+		return "", fmt.Errorf("Lirex Compile: Ref: unknown rule '%s'.", node.name)
+	}
+	return expandRule(rule, ctx)
+}
+func expandRule(rule RuleNode, ctx *CompileContext) (string, error) {
+	g := ctx.grammar
+	if g.recursive[rule.name] && g.depth[rule.name] >= ctx.maxRecursion {
+		return neverMatch, nil
+	}
+	g.depth[rule.name]++
+	g.expanding = append(g.expanding, rule.name)
+	defer func() {
+		g.depth[rule.name]--
+		g.expanding = g.expanding[:len(g.expanding)-1]
+	}()
+
+	compiled, err := compileNodes(rule.children, ctx)
+	if err != nil {
+		return "", err
+	}
+	if compiled == "" {
+		return "", handleEmptyNode(rule, ctx)
+	}
+	if len(compiled) > maxRuleSize {
+		return "", fmt.Errorf("Lirex Compile: Rule '%s' expands to more than %d bytes. Lower Options.MaxRecursion.", rule.name, maxRuleSize)
+	}
+	return "(?:" + compiled + ")", nil
+}
+
+// Records that name (a group, or a helper as "helper:" + name) was taken, by the innermost rule
+// being expanded if any. Reports whether it was free or taken by a rule being expanded again.
+func (g *grammar) take(name string, taken bool) bool {
+	if owner, ok := g.owners[name]; taken {
+		return ok && g.depth[owner] > 0
+	}
+	if len(g.expanding) > 0 {
+		g.owners[name] = g.expanding[len(g.expanding)-1]
+	}
+	return true
+}
+
+// Collects the rules defined in the tree and finds the recursive ones.
+func newGrammar(nodes []Node, maxRecursion uint) (*grammar, error) {
+	g := &grammar{
+		rules:     map[string]RuleNode{},
+		recursive: map[string]bool{},
+		depth:     map[string]uint{},
+		owners:    map[string]string{},
+	}
+	var collect func(nodes []Node) error
+	collect = func(nodes []Node) error {
+		for _, node := range nodes {
+			if rule, ok := node.(RuleNode); ok {
+				if rule.name == "" {
+					return fmt.Errorf("Lirex Compile: Rule must have a name.")
+				}
+				if _, exists := g.rules[rule.name]; exists {
+					return fmt.Errorf("Lirex Compile: Rule '%s' defined more than once.", rule.name)
+				}
+				g.rules[rule.name] = rule
+			}
+			if err := collect(childNodes(node)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := collect(nodes); err != nil {
+		return nil, err
+	}
+
+	// Rules used by each rule, either through Ref or a nested Rule.
+	uses := map[string][]string{}
+	for name, rule := range g.rules {
+		var walk func(nodes []Node)
+		walk = func(nodes []Node) {
+			for _, node := range nodes {
+				switch node := node.(type) {
+				case RefNode:
+					uses[name] = append(uses[name], node.name)
+				case RuleNode:
+					uses[name] = append(uses[name], node.name)
+					continue
+				}
+				walk(childNodes(node))
+			}
+		}
+		walk(rule.children)
+	}
+
+	names := make([]string, 0, len(g.rules))
+	for name := range g.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		seen := map[string]bool{}
+		stack := append([]string(nil), uses[name]...)
+		for len(stack) > 0 {
+			next := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if next == name {
+				g.recursive[name] = true
+				break
+			}
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, uses[next]...)
+			}
+		}
+		if g.recursive[name] && maxRecursion == 0 {
+			return nil, fmt.Errorf("Lirex Compile: Rule '%s' is recursive. Set Options.MaxRecursion to bound its expansion.", name)
+		}
+	}
+	return g, nil
+}
+
+// Direct children of container nodes.
+func childNodes(node Node) []Node {
+	switch node := node.(type) {
+	case SeqNode:
+		return node.nodes
+	case GroupNode:
+		return node.children
+	case OrNode:
+		return node.children
+	case CaptureNode:
+		return node.children
+	case RuleNode:
+		return node.children
+	case NamespaceNode:
+		return node.children
+	case AtLeastRepeatNode:
+		return []Node{node.child}
+	case ExactlyRepeatNode:
+		return []Node{node.child}
+	case BetweenRepeatNode:
+		return []Node{node.child}
+	case OptionalRepeatNode:
+		return []Node{node.child}
+	case ListNode:
+		return []Node{node.item, node.sep}
+	case AnyOrderNode:
+		return node.children
+	}
+	return nil
+}
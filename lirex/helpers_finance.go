@@ -0,0 +1,366 @@
+package lirex
+
+import (
+	_ "embed"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IBAN length per country, one "country length" line each.
+//
+//go:embed iban_lengths.txt
+var ibanRegistry string
+
+var ibanLengths = parseIBANLengths(ibanRegistry)
+
+func parseIBANLengths(registry string) map[string]int {
+	lengths := map[string]int{}
+	for _, line := range strings.Split(registry, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		country, length, _ := strings.Cut(line, " ")
+		n, err := strconv.Atoi(length)
+		if err != nil {
+			panic("Lirex: bad line in iban_lengths.txt: " + line)
+		}
+		lengths[country] = n
+	}
+	return lengths
+}
+
+// One alternative per IBAN length, longest first, each listing the countries using that length.
+// Matches the electronic form and the print form, in groups of four separated by spaces.
+func iban() CaptureNode {
+	byLength := map[int][]string{}
+	for country, length := range ibanLengths {
+		byLength[length] = append(byLength[length], country)
+	}
+	lengths := []int{}
+	for length := range byLength {
+		lengths = append(lengths, length)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+
+	alts := []Node{}
+	for _, length := range lengths {
+		countries := byLength[length]
+		sort.Strings(countries)
+		var country Node = Lit(countries[0])
+		if len(countries) > 1 {
+			country = words(countries)
+		}
+		bban := CharClass(UpperLatin, Digit)
+		spaced := []Node{Group(Seq(Lit(" "), bban.Exactly(4))).Exactly(uint(length-4) / 4)}
+		if rest := uint(length-4) % 4; rest > 0 {
+			spaced = append(spaced, Lit(" "), bban.Exactly(rest))
+		}
+		alts = append(alts, Seq(
+			country,
+			Digit.Exactly(2),
+			Or(bban.Exactly(uint(length-4)), Seq(spaced...)),
+		))
+	}
+	return Capture("IBAN", WordBoundary, Or(alts...), WordBoundary)
+}
+
+// Checks the country-specific length and the ISO 13616 mod-97 checksum. Takes the forms
+// Helpers.IBAN matches: electronic, or printed in groups of four separated by spaces. Lowercase
+// letters are accepted, as the expression matches them under Options.CaseInsensitive.
+func ValidIBAN(value string) bool {
+	value, ok := compactIBAN(value)
+	if !ok || len(value) < 4 || ibanLengths[value[:2]] != len(value) {
+		return false
+	}
+	digits, ok := alnumToDigits(value[4:] + value[:4])
+	if !ok {
+		return false
+	}
+	n, _ := new(big.Int).SetString(digits, 10)
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// Electronic form of an IBAN in either form, uppercased. Not ok for spaces anywhere else than
+// between groups of four.
+func compactIBAN(value string) (string, bool) {
+	if !strings.Contains(value, " ") {
+		return strings.ToUpper(value), true
+	}
+	groups := strings.Split(value, " ")
+	for i, group := range groups {
+		last := i == len(groups)-1
+		if len(group) != 4 && !(last && 0 < len(group) && len(group) < 4) {
+			return "", false
+		}
+	}
+	return strings.ToUpper(strings.Join(groups, "")), true
+}
+
+func bic() CaptureNode {
+	return Capture("BIC",
+		WordBoundary,
+		Capture("BIC_bank", UpperLatin.Exactly(4)),
+		Capture("BIC_country", UpperLatin.Exactly(2)),
+		Capture("BIC_location", CharClass(UpperLatin, Digit).Exactly(2)),
+		Group(Seq(Capture("BIC_branch", CharClass(UpperLatin, Digit).Exactly(3)))).Optional(),
+		WordBoundary,
+	)
+}
+
+func isin() CaptureNode {
+	return Capture("ISIN",
+		WordBoundary,
+		UpperLatin.Exactly(2),
+		CharClass(UpperLatin, Digit).Exactly(9),
+		Digit,
+		WordBoundary,
+	)
+}
+
+// Letters expand to two digits (A=10 ... Z=35), then the Luhn check runs over the digits.
+func ValidISIN(value string) bool {
+	if len(value) != 12 {
+		return false
+	}
+	digits, ok := alnumToDigits(value)
+	return ok && luhn(digits)
+}
+
+func cusip() CaptureNode {
+	return Capture("CUSIP",
+		WordBoundary,
+		CharClass(UpperLatin, Digit, Lit("*@#")).Exactly(8),
+		Digit,
+		WordBoundary,
+	)
+}
+
+// Check digit: weighted sum (every second value doubled) of the first eight characters.
+func ValidCUSIP(value string) bool {
+	if len(value) != 9 || value[8] < '0' || value[8] > '9' {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 8; i++ {
+		var v int
+		switch c := value[i]; {
+		case c >= '0' && c <= '9':
+			v = int(c - '0')
+		case c >= 'A' && c <= 'Z':
+			v = int(c-'A') + 10
+		case c == '*':
+			v = 36
+		case c == '@':
+			v = 37
+		case c == '#':
+			v = 38
+		default:
+			return false
+		}
+		if i%2 == 1 {
+			v *= 2
+		}
+		sum += v/10 + v%10
+	}
+	return (10-sum%10)%10 == int(value[8]-'0')
+}
+
+// First two digits are a Federal Reserve routing symbol: 00-12, 21-32, 61-72 or 80.
+func abaRouting() CaptureNode {
+	return Capture("ABA",
+		WordBoundary,
+		Or(digitRange(0, 12, 2), digitRange(21, 32, 2), digitRange(61, 72, 2), Lit("80")),
+		Digit.Exactly(7),
+		WordBoundary,
+	)
+}
+
+// Checksum: 3*(d1+d4+d7) + 7*(d2+d5+d8) + (d3+d6+d9) must be divisible by 10.
+func ValidABA(value string) bool {
+	if len(value) != 9 {
+		return false
+	}
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+		sum += weights[i%3] * int(value[i]-'0')
+	}
+	return sum%10 == 0
+}
+
+func luhn(digits string) bool {
+	if digits == "" {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		c := digits[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		v := int(c - '0')
+		if double {
+			if v *= 2; v > 9 {
+				v -= 9
+			}
+		}
+		sum += v
+		double = !double
+	}
+	return sum%10 == 0
+}
+func alnumToDigits(value string) (string, bool) {
+	var b strings.Builder
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			b.WriteString(strconv.Itoa(int(c-'A') + 10))
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+type cardBrand struct {
+	name   string
+	length int
+	// Inclusive ranges of the first four digits
+	ranges [][2]uint
+}
+
+var cardBrands = []cardBrand{
+	{"Visa", 16, [][2]uint{{4000, 4999}}},
+	{"Mastercard", 16, [][2]uint{{5100, 5599}, {2221, 2720}}},
+	{"Amex", 15, [][2]uint{{3400, 3499}, {3700, 3799}}},
+	{"Discover", 16, [][2]uint{{6011, 6011}, {6440, 6599}}},
+	{"JCB", 16, [][2]uint{{3528, 3589}}},
+	{"UnionPay", 16, [][2]uint{{6200, 6299}}},
+}
+
+// CreditCard_brand captures the leading 4-digit block, which identifies the brand: a regex can only
+// capture text, so it holds e.g. "4111", and CardBrand turns it (or the whole match) into "Visa".
+// Amex numbers are grouped 4-6-5, all others 4-4-4-4; each issuer range carries its own grouping
+// so they can't be mixed.
+func creditCard() CaptureNode {
+	amex, others := []Node{}, []Node{}
+	for _, brand := range cardBrands {
+		for _, r := range brand.ranges {
+			if brand.length == 15 {
+				amex = append(amex, digitRange(r[0], r[1], 4))
+			} else {
+				others = append(others, digitRange(r[0], r[1], 4))
+			}
+		}
+	}
+	sep := CharClass(Lit(" -")).Optional()
+	return Capture("CreditCard",
+		WordBoundary,
+		cardNode{branches: []Node{
+			Seq(
+				Capture("CreditCard_brand", Or(amex...)),
+				sep,
+				Capture("CreditCard_group2", Digit.Exactly(6)),
+				sep,
+				Capture("CreditCard_group3", Digit.Exactly(5)),
+			),
+			Seq(
+				Capture("CreditCard_brand", Or(others...)),
+				sep,
+				Capture("CreditCard_group2", Digit.Exactly(4)),
+				sep,
+				Capture("CreditCard_group3", Digit.Exactly(4)),
+				sep,
+				Capture("CreditCard_group4", Digit.Exactly(4)),
+			),
+		}},
+		WordBoundary,
+	)
+}
+
+// Or of the issuer branches of a card number. The branches repeat the same capture names (Go
+// allows duplicate group names), so each branch is compiled with the names taken before it.
+type cardNode struct{ branches []Node }
+
+func (node cardNode) compile(ctx *CompileContext) (string, error) {
+	before, taken := ctx.groupNames, map[string]struct{}{}
+	defer func() { ctx.groupNames = taken }()
+	branches := []string{}
+	for _, branch := range node.branches {
+		ctx.groupNames = map[string]struct{}{}
+		for name := range before {
+			ctx.groupNames[name] = struct{}{}
+		}
+		compiled, err := branch.compile(ctx)
+		if err != nil {
+			return "", err
+		}
+		for name := range ctx.groupNames {
+			taken[name] = struct{}{}
+		}
+		branches = append(branches, compiled)
+	}
+	return "(?:" + strings.Join(branches, "|") + ")", nil
+}
+
+func (node cardNode) explain() string { return Or(node.branches...).explain() }
+
+// Strips separators from a card number match.
+func CardDigits(match string) string {
+	var b strings.Builder
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Returns the issuer of a card number ("Visa", "Mastercard", "Amex", "Discover", "JCB", "UnionPay")
+// or "" if the prefix is unknown. Separators are ignored.
+func CardBrand(number string) string {
+	digits := CardDigits(number)
+	if len(digits) < 4 {
+		return ""
+	}
+	prefix, _ := strconv.ParseUint(digits[:4], 10, 64)
+	for _, brand := range cardBrands {
+		for _, r := range brand.ranges {
+			if uint(prefix) >= r[0] && uint(prefix) <= r[1] {
+				return brand.name
+			}
+		}
+	}
+	return ""
+}
+
+// Checks the brand, the brand's length and grouping, and the Luhn checksum.
+func ValidCard(match string) bool {
+	digits := CardDigits(match)
+	name := CardBrand(digits)
+	for _, brand := range cardBrands {
+		if brand.name != name {
+			continue
+		}
+		if len(digits) != brand.length || !luhn(digits) {
+			return false
+		}
+		groups := strings.FieldsFunc(match, func(c rune) bool { return c == ' ' || c == '-' })
+		if len(groups) == 1 {
+			return true
+		}
+		if brand.length == 15 {
+			return len(groups) == 3 && len(groups[1]) == 6
+		}
+		return len(groups) == 4 && len(groups[1]) == 4
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package lirex
+
+import "testing"
+
+func TestValidIBAN(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"GB82WEST12345698765432", true},
+		{"GB82 WEST 1234 5698 7654 32", true},
+		{"gb82 west 1234 5698 7654 32", true},
+		{"DE89370400440532013000", true},
+		{"FR1420041010050500013M02606", true},
+		{"GB82WEST12345698765433", false},
+		{"GB28WEST12345698765432", false},
+		{"GB82WEST1234569876543", false},
+		{"DE8937040044053201300", false},
+		{"XX82WEST12345698765432", false},
+		{"GB82WEST1234569876543!", false},
+		{"GB82 WEST 1234 5698 7654 32 ", false},
+		{"GB 82WEST12345698765432", false},
+		{"GB82  WEST 1234 5698 7654 32", false},
+		{"GB82WEST 1234 5698 7654 32", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := ValidIBAN(test.value); got != test.want {
+			t.Errorf("ValidIBAN(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestValidISIN(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"US0378331005", true},
+		{"AU0000XVGZA3", true},
+		{"GB0002634946", true},
+		{"DE000BAY0017", true},
+		{"US0378331006", false},
+		{"AU0000XVGZA4", false},
+		{"US037833100", false},
+		{"us0378331005", false},
+	}
+	for _, test := range tests {
+		if got := ValidISIN(test.value); got != test.want {
+			t.Errorf("ValidISIN(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestValidCUSIP(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"037833100", true},
+		{"38259P508", true},
+		{"594918104", true},
+		{"037833101", false},
+		{"38259P509", false},
+		{"03783310", false},
+		{"03783310A", false},
+		{"0378-3100", false},
+	}
+	for _, test := range tests {
+		if got := ValidCUSIP(test.value); got != test.want {
+			t.Errorf("ValidCUSIP(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestValidABA(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"011000015", true},
+		{"021000021", true},
+		{"122105278", true},
+		{"011000016", false},
+		{"021000012", false},
+		{"02100002", false},
+		{"02100002a", false},
+	}
+	for _, test := range tests {
+		if got := ValidABA(test.value); got != test.want {
+			t.Errorf("ValidABA(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestValidCard(t *testing.T) {
+	tests := []struct {
+		value string
+		brand string
+		want  bool
+	}{
+		{"4111111111111111", "Visa", true},
+		{"4111 1111 1111 1111", "Visa", true},
+		{"4111-1111-1111-1111", "Visa", true},
+		{"5555555555554444", "Mastercard", true},
+		{"2223000048400011", "Mastercard", true},
+		{"378282246310005", "Amex", true},
+		{"3782 822463 10005", "Amex", true},
+		{"6011111111111117", "Discover", true},
+		{"3530111333300000", "JCB", true},
+		{"4111 1111 1111 1112", "Visa", false},
+		{"4111 111111 111111", "Visa", false},
+		{"3782 8224 6310 005", "Amex", false},
+		{"37828224631000", "Amex", false},
+		{"9111111111111111", "", false},
+	}
+	for _, test := range tests {
+		if got := CardBrand(test.value); got != test.brand {
+			t.Errorf("CardBrand(%q) = %q, want %q", test.value, got, test.brand)
+		}
+		if got := ValidCard(test.value); got != test.want {
+			t.Errorf("ValidCard(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestCreditCardGrouping(t *testing.T) {
+	re := Exp(Helpers.CreditCard).MustCompile(Options{})
+	tests := []struct {
+		text   string
+		issuer string
+	}{
+		{"card 4111 1111 1111 1111.", "4111"},
+		{"card 3782 822463 10005.", "3782"},
+		{"card 378282246310005.", "3782"},
+		{"card 4111 111111 11111.", ""},
+		{"card 3782 8224 6310 0050.", ""},
+	}
+	for _, test := range tests {
+		captures, ok := FindCaptures(re, test.text)
+		issuer := ""
+		if ok {
+			for _, value := range captures["CreditCard_brand"] {
+				if value != "" {
+					issuer = value
+				}
+			}
+		}
+		if issuer != test.issuer {
+			t.Errorf("CreditCard issuer in %q = %q, want %q", test.text, issuer, test.issuer)
+		}
+	}
+}
+
+// Helpers.IBAN matches the forms ValidIBAN accepts, so FindAllValid keeps both.
+func TestIBANForms(t *testing.T) {
+	re := Exp(Helpers.IBAN).MustCompile(Options{})
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"pay GB82WEST12345698765432 now", "GB82WEST12345698765432"},
+		{"pay GB82 WEST 1234 5698 7654 32 now", "GB82 WEST 1234 5698 7654 32"},
+		{"pay FR14 2004 1010 0505 0001 3M02 606.", "FR14 2004 1010 0505 0001 3M02 606"},
+		{"pay GB82 WEST 1234 5698 7654 33 now", ""},
+		{"pay GB82 WEST12 3456 9876 5432 now", ""},
+	}
+	for _, test := range tests {
+		got := ""
+		if found := Helpers.IBAN.FindAllValid(re, test.text); len(found) > 0 {
+			got = found[0]
+		}
+		if got != test.want {
+			t.Errorf("IBAN in %q = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
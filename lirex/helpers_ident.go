@@ -0,0 +1,186 @@
+package lirex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func uuid(versions Node) CaptureNode {
+	return Capture("UUID",
+		WordBoundary,
+		HexDigit.Exactly(8),
+		Lit("-"),
+		HexDigit.Exactly(4),
+		Lit("-"),
+		Capture("UUID_version", versions),
+		HexDigit.Exactly(3),
+		Lit("-"),
+		CharClass(Lit("89abAB")),
+		HexDigit.Exactly(3),
+		Lit("-"),
+		HexDigit.Exactly(12),
+		WordBoundary,
+	)
+}
+
+// Helpers.UUID restricted to the given versions (1-8). Shares the UUID capture names with Helpers.UUID.
+func UUIDVersion(versions ...uint) HelperNode {
+	if len(versions) == 0 {
+		return HelperNode{name: "UUID", err: fmt.Errorf("Lirex Compile: UUIDVersion() needs at least one version.")}
+	}
+	digits := ""
+	for _, version := range versions {
+		if version < 1 || version > 8 {
+			return HelperNode{name: "UUID", err: fmt.Errorf("Lirex Compile: UUIDVersion(): invalid UUID version %d, expected 1-8.", version)}
+		}
+		digits += strconv.FormatUint(uint64(version), 10)
+	}
+	return compile(uuid(CharClass(Lit(digits))), "UUID", "UUID_version").
+		describe("UUID (RFC 9562) of version " + digits + ", e.g. 550e8400-e29b-41d4-a716-446655440000")
+}
+
+func ulid() CaptureNode {
+	return Capture("ULID",
+		WordBoundary,
+		RuneCharNode{value: `[0-7]`},
+		RuneCharNode{value: `[0-9A-HJKMNP-TV-Z]`}.Exactly(25),
+		WordBoundary,
+	)
+}
+
+func semVerNumber() OrNode {
+	return Or(Lit("0"), Seq(RuneCharNode{value: `[1-9]`}, Digit.ZeroOrMore()))
+}
+func semVer() CaptureNode {
+	// Alphanumeric identifiers go first: "0alpha" must not stop after "0".
+	preIdent := Or(
+		Seq(Digit.ZeroOrMore(), CharClass(Latin, Lit("-")), CharClass(LatinDigit, Lit("-")).ZeroOrMore()),
+		semVerNumber(),
+	)
+	buildIdent := CharClass(LatinDigit, Lit("-")).AtLeast(1)
+	return Capture("SemVer",
+		Capture("SemVer_major", semVerNumber()),
+		Lit("."),
+		Capture("SemVer_minor", semVerNumber()),
+		Lit("."),
+		Capture("SemVer_patch", semVerNumber()),
+		Group(
+			Lit("-"),
+			Capture("SemVer_prerelease", preIdent, Group(Lit("."), preIdent).ZeroOrMore()),
+		).Optional(),
+		Group(
+			Lit("+"),
+			Capture("SemVer_build", buildIdent, Group(Lit("."), buildIdent).ZeroOrMore()),
+		).Optional(),
+	)
+}
+
+var semVerExp = Exp(LineStart, semVer(), LineEnd).MustCompile(Options{})
+
+// Compares two semantic versions by SemVer 2.0.0 precedence: -1 if a < b, 0 if equal, +1 if a > b.
+// Build metadata is ignored.
+func CompareSemVer(a, b string) (int, error) {
+	pa, err := parseSemVer(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseSemVer(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < 3; i++ {
+		if c := compareNumeric(pa[i], pb[i]); c != 0 {
+			return c, nil
+		}
+	}
+
+	// A version without prerelease has higher precedence than one with it.
+	switch preA, preB := pa[3], pb[3]; {
+	case preA == preB:
+		return 0, nil
+	case preA == "":
+		return 1, nil
+	case preB == "":
+		return -1, nil
+	}
+	idsA, idsB := strings.Split(pa[3], "."), strings.Split(pb[3], ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		if c := comparePrerelease(idsA[i], idsB[i]); c != 0 {
+			return c, nil
+		}
+	}
+	return compareInts(len(idsA), len(idsB)), nil
+}
+
+// Returns major, minor, patch, prerelease.
+func parseSemVer(version string) ([4]string, error) {
+	match := semVerExp.FindStringSubmatch(version)
+	if match == nil {
+		return [4]string{}, fmt.Errorf("Lirex: invalid semantic version '%s'.", version)
+	}
+	result := [4]string{}
+	for i, name := range []string{"SemVer_major", "SemVer_minor", "SemVer_patch", "SemVer_prerelease"} {
+		result[i] = match[semVerExp.SubexpIndex(name)]
+	}
+	return result, nil
+}
+func comparePrerelease(a, b string) int {
+	_, errA := strconv.ParseUint(a, 10, 64)
+	_, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return compareNumeric(a, b)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Compares digit strings without leading zeros, so arbitrarily large numbers work.
+func compareNumeric(a, b string) int {
+	if c := compareInts(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func hexColor() CaptureNode {
+	return Capture("HexColor",
+		Lit("#"),
+		Or(HexDigit.Exactly(8), HexDigit.Exactly(6), HexDigit.Exactly(4), HexDigit.Exactly(3)),
+		WordBoundary,
+	)
+}
+func hexDigest(name string, length uint) CaptureNode {
+	return Capture(name, WordBoundary, HexDigit.Exactly(length), WordBoundary)
+}
+
+// At least 16 characters, so ordinary words aren't reported as tokens.
+func base64() CaptureNode {
+	chars := CharClass(LatinDigit, Lit("+/"))
+	return Capture("Base64",
+		Group(Seq(chars.Exactly(4))).AtLeast(4),
+		Or(
+			Seq(chars.Exactly(3), Lit("=")),
+			Seq(chars.Exactly(2), Lit("==")),
+		).Optional(),
+	)
+}
+func base64URL() CaptureNode {
+	return Capture("Base64URL",
+		CharClass(LatinDigit, Lit("_-")).AtLeast(16),
+		Lit("=").Between(0, 2),
+	)
+}
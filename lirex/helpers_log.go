@@ -0,0 +1,332 @@
+package lirex
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	accessLogLayout = "02/Jan/2006:15:04:05 -0700"
+	syslogLayout    = "Jan _2 15:04:05"
+)
+
+// Timestamp of a Go reference layout without component captures.
+func logTime(layout string) Node {
+	exp, _, err := timeLayout(layout, "Time")
+	if err != nil {
+		panic(err)
+	}
+	return uncaptured(exp)
+}
+
+func field(name string) CaptureNode {
+	return Capture(name, NonWhitespace.AtLeast(1))
+}
+
+// ACCESS LOG ---------------------------------------------------------------------------
+// Apache/nginx common log format, plus referer and user agent if combined:
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://x/" "Mozilla/5.0"
+func accessLog(prefix string, combined bool) CaptureNode {
+	nodes := []Node{
+		field(prefix + "_remoteAddr"), Lit(" "),
+		field(prefix + "_ident"), Lit(" "),
+		field(prefix + "_user"), Lit(" ["),
+		Capture(prefix+"_time", logTime(accessLogLayout)), Lit(`] "`),
+		Or(
+			Seq(
+				Capture(prefix+"_method", UpperLatin.AtLeast(1)), Lit(" "),
+				Capture(prefix+"_path", NotCharClass(Whitespace, Lit(`"`)).AtLeast(1)),
+				Group(Lit(" "), Capture(prefix+"_protocol", Lit("HTTP/"), CharClass(Digit, Lit(".")).AtLeast(1))).Optional(),
+			),
+			Lit("-"),
+		),
+		Lit(`" `),
+		Capture(prefix+"_status", Digit.Exactly(3)), Lit(" "),
+		Capture(prefix+"_bytes", Or(Digit.AtLeast(1), Lit("-"))),
+	}
+	if combined {
+		nodes = append(nodes,
+			Lit(" "), Quoted('"', '"', QuoteOptions{Escape: '\\', Capture: prefix + "_referer"}),
+			Lit(" "), Quoted('"', '"', QuoteOptions{Escape: '\\', Capture: prefix + "_userAgent"}),
+		)
+	}
+	return Capture(prefix, nodes...)
+}
+
+type AccessLogEntry struct {
+	RemoteAddr string
+	Ident      string
+	User       string
+	Time       time.Time
+	Method     string
+	Path       string
+	Protocol   string
+	Status     int
+	// -1 if the log has "-"
+	Bytes     int64
+	Referer   string
+	UserAgent string
+}
+
+var (
+	commonLogExp   = Exp(LineStart, Helpers.CommonLog).MustCompile(Options{})
+	combinedLogExp = Exp(LineStart, Helpers.CombinedLog).MustCompile(Options{})
+)
+
+// Decodes a line in common log format.
+func ParseCommonLog(line string) (AccessLogEntry, error) {
+	return parseAccessLog(commonLogExp, "CommonLog", line)
+}
+
+// Decodes a line in combined log format.
+func ParseCombinedLog(line string) (AccessLogEntry, error) {
+	return parseAccessLog(combinedLogExp, "CombinedLog", line)
+}
+
+func parseAccessLog(re *regexp.Regexp, prefix, line string) (AccessLogEntry, error) {
+	match, ok := FindMatch(re, line)
+	if !ok {
+		return AccessLogEntry{}, fmt.Errorf("Lirex Log: line is not in %s format.", prefix)
+	}
+	m := match.Scope(prefix)
+	entry := AccessLogEntry{
+		RemoteAddr: m.Get("remoteAddr"),
+		Ident:      m.Get("ident"),
+		User:       m.Get("user"),
+		Method:     m.Get("method"),
+		Path:       m.Get("path"),
+		Protocol:   m.Get("protocol"),
+		Bytes:      -1,
+		Referer:    unescapeLogQuote(m.Get("referer")),
+		UserAgent:  unescapeLogQuote(m.Get("userAgent")),
+	}
+	var err error
+	if entry.Time, err = time.Parse(accessLogLayout, m.Get("time")); err != nil {
+		return AccessLogEntry{}, fmt.Errorf("Lirex Log: %w", err)
+	}
+	if entry.Status, err = strconv.Atoi(m.Get("status")); err != nil {
+		return AccessLogEntry{}, fmt.Errorf("Lirex Log: %w", err)
+	}
+	if bytes := m.Get("bytes"); bytes != "-" {
+		if entry.Bytes, err = strconv.ParseInt(bytes, 10, 64); err != nil {
+			return AccessLogEntry{}, fmt.Errorf("Lirex Log: %w", err)
+		}
+	}
+	return entry, nil
+}
+
+func unescapeLogQuote(s string) string {
+	return Quoted('"', '"', QuoteOptions{Escape: '\\'}).Unescape(s)
+}
+
+// SYSLOG -------------------------------------------------------------------------------
+// RFC 3164 (BSD) syslog: <34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed
+func syslog3164() CaptureNode {
+	return Capture("Syslog3164",
+		Group(Lit("<"), Capture("Syslog3164_priority", digitRange(0, 191, 0)), Lit(">")).Optional(),
+		Capture("Syslog3164_time", logTime(syslogLayout)), Lit(" "),
+		field("Syslog3164_hostname"), Lit(" "),
+		Group(
+			Capture("Syslog3164_tag", NotCharClass(Whitespace, Lit("[:")).AtLeast(1)),
+			Group(Lit("["), Capture("Syslog3164_pid", NotCharClass(Lit("]")).AtLeast(1)), Lit("]")).Optional(),
+			Lit(":"),
+			Whitespace.ZeroOrMore(),
+		).Optional(),
+		Capture("Syslog3164_message", AnyChar.ZeroOrMore()),
+	)
+}
+
+// RFC 5424 syslog:
+// <165>1 2003-10-11T22:14:15.003Z host app 1234 ID47 [exampleSDID@32473 iut="3"] message
+func syslog5424() CaptureNode {
+	nilOr := func(node Node) Node { return Or(Lit("-"), node) }
+	return Capture("Syslog5424",
+		Lit("<"), Capture("Syslog5424_priority", digitRange(0, 191, 0)), Lit(">"),
+		Capture("Syslog5424_version", digitRange(1, 999, 0)), Lit(" "),
+		Capture("Syslog5424_time", nilOr(logTime(time.RFC3339))), Lit(" "),
+		field("Syslog5424_hostname"), Lit(" "),
+		field("Syslog5424_appName"), Lit(" "),
+		field("Syslog5424_procID"), Lit(" "),
+		field("Syslog5424_msgID"), Lit(" "),
+		Capture("Syslog5424_structuredData", nilOr(sdElement().AtLeast(1))),
+		Group(Lit(" "), Capture("Syslog5424_message", AnyChar.ZeroOrMore())).Optional(),
+	)
+}
+
+// [id name="value" ...], values with \" \\ and \] escapes.
+func sdElement() GroupNode {
+	name := NotCharClass(Whitespace, Lit(`="]`)).AtLeast(1)
+	return Group(
+		Lit("["), name,
+		Group(Lit(" "), name, Lit("="), Quoted('"', '"', QuoteOptions{Escape: '\\'})).ZeroOrMore(),
+		Lit("]"),
+	)
+}
+
+type SyslogMessage struct {
+	// -1 if the line has no <priority>
+	Priority int
+	Facility int
+	Severity int
+	// 0 for RFC 3164
+	Version int
+	// RFC 3164 timestamps have no year; Time is in year 0 and UTC. Zero if the line has "-".
+	Time     time.Time
+	Hostname string
+	AppName  string
+	ProcID   string
+	MsgID    string
+	// SD-ID => param name => value (RFC 5424)
+	StructuredData map[string]map[string]string
+	Message        string
+}
+
+var (
+	syslog3164Exp = Exp(LineStart, Helpers.Syslog3164).MustCompile(Options{})
+	syslog5424Exp = Exp(LineStart, Helpers.Syslog5424).MustCompile(Options{})
+	sdElementExp  = Exp(
+		Lit("["), Capture("id", NotCharClass(Whitespace, Lit(`="]`)).AtLeast(1)),
+		Capture("params", Group(Lit(" "), NotCharClass(Whitespace, Lit(`="]`)).AtLeast(1), Lit("="), Quoted('"', '"', QuoteOptions{Escape: '\\'})).ZeroOrMore()),
+		Lit("]"),
+	).MustCompile(Options{})
+	sdParamExp = Exp(
+		Lit(" "), Capture("name", NotCharClass(Whitespace, Lit(`="]`)).AtLeast(1)),
+		Lit("="), Quoted('"', '"', QuoteOptions{Escape: '\\', Capture: "value"}),
+	).MustCompile(Options{})
+)
+
+// Decodes an RFC 3164 (BSD) syslog line.
+func ParseSyslog3164(line string) (SyslogMessage, error) {
+	match, ok := FindMatch(syslog3164Exp, line)
+	if !ok {
+		return SyslogMessage{}, fmt.Errorf("Lirex Log: line is not in RFC 3164 syslog format.")
+	}
+	m := match.Scope("Syslog3164")
+	msg := SyslogMessage{
+		Priority: -1,
+		Facility: -1,
+		Severity: -1,
+		Hostname: m.Get("hostname"),
+		AppName:  m.Get("tag"),
+		ProcID:   m.Get("pid"),
+		Message:  m.Get("message"),
+	}
+	if err := msg.setPriority(m.Get("priority")); err != nil {
+		return SyslogMessage{}, err
+	}
+	var err error
+	if msg.Time, err = time.Parse(syslogLayout, m.Get("time")); err != nil {
+		return SyslogMessage{}, fmt.Errorf("Lirex Log: %w", err)
+	}
+	return msg, nil
+}
+
+// Decodes an RFC 5424 syslog line. "-" (nil) fields are returned empty.
+func ParseSyslog5424(line string) (SyslogMessage, error) {
+	match, ok := FindMatch(syslog5424Exp, line)
+	if !ok {
+		return SyslogMessage{}, fmt.Errorf("Lirex Log: line is not in RFC 5424 syslog format.")
+	}
+	m := match.Scope("Syslog5424")
+	nilValue := func(s string) string {
+		if s == "-" {
+			return ""
+		}
+		return s
+	}
+	msg := SyslogMessage{
+		Hostname:       nilValue(m.Get("hostname")),
+		AppName:        nilValue(m.Get("appName")),
+		ProcID:         nilValue(m.Get("procID")),
+		MsgID:          nilValue(m.Get("msgID")),
+		StructuredData: map[string]map[string]string{},
+		Message:        strings.TrimPrefix(m.Get("message"), "\ufeff"),
+	}
+	if err := msg.setPriority(m.Get("priority")); err != nil {
+		return SyslogMessage{}, err
+	}
+	var err error
+	if msg.Version, err = strconv.Atoi(m.Get("version")); err != nil {
+		return SyslogMessage{}, fmt.Errorf("Lirex Log: %w", err)
+	}
+	if stamp := m.Get("time"); stamp != "-" {
+		if msg.Time, err = time.Parse(time.RFC3339Nano, stamp); err != nil {
+			return SyslogMessage{}, fmt.Errorf("Lirex Log: %w", err)
+		}
+	}
+	unescape := Quoted('"', '"', QuoteOptions{Escape: '\\'})
+	for _, element := range FindAllMatches(sdElementExp, nilValue(m.Get("structuredData")), -1) {
+		params := map[string]string{}
+		for _, param := range FindAllMatches(sdParamExp, element.Get("params"), -1) {
+			params[param.Get("name")] = unescape.Unescape(param.Get("value"))
+		}
+		msg.StructuredData[element.Get("id")] = params
+	}
+	return msg, nil
+}
+
+func (msg *SyslogMessage) setPriority(priority string) error {
+	if priority == "" {
+		return nil
+	}
+	value, err := strconv.Atoi(priority)
+	if err != nil {
+		return fmt.Errorf("Lirex Log: %w", err)
+	}
+	msg.Priority, msg.Facility, msg.Severity = value, value/8, value%8
+	return nil
+}
+
+// LOGFMT -------------------------------------------------------------------------------
+// key=value pairs separated by spaces; values are bare or double-quoted with \ escapes.
+func logfmt() CaptureNode {
+	return Capture("Logfmt", List(logfmtPair(""), Whitespace.AtLeast(1), 1, 0))
+}
+
+func logfmtPair(capture string) Node {
+	key := NotCharClass(Whitespace, Lit(`="`)).AtLeast(1)
+	value := Or(
+		Quoted('"', '"', QuoteOptions{Escape: '\\'}),
+		NotCharClass(Whitespace, Lit(`"`)).AtLeast(1),
+	)
+	if capture == "" {
+		return Group(key, Group(Lit("="), value.Optional()).Optional())
+	}
+	return Group(Capture(capture+"key", key), Group(Lit("="), Capture(capture+"value", value.Optional())).Optional())
+}
+
+type LogfmtField struct {
+	Key   string
+	Value string
+}
+
+var logfmtPairExp = Exp(logfmtPair("logfmt_")).MustCompile(Options{})
+
+// Decodes a logfmt line into its fields in order. Quoted values are unescaped;
+// a key without "=" has an empty value.
+func ParseLogfmt(line string) ([]LogfmtField, error) {
+	unescape := Quoted('"', '"', QuoteOptions{Escape: '\\'})
+	fields := []LogfmtField{}
+	rest := strings.TrimSpace(line)
+	for rest != "" {
+		loc := logfmtPairExp.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 {
+			return nil, fmt.Errorf("Lirex Log: invalid logfmt at %q.", rest)
+		}
+		match := newMatch(logfmtPairExp, rest, loc)
+		value := match.Get("logfmt_value")
+		if len(value) >= 2 && value[0] == '"' {
+			value = unescape.Unescape(value[1 : len(value)-1])
+		}
+		fields = append(fields, LogfmtField{Key: match.Get("logfmt_key"), Value: value})
+		after := rest[loc[1]:]
+		if after != "" && after[0] != ' ' && after[0] != '\t' {
+			return nil, fmt.Errorf("Lirex Log: invalid logfmt at %q.", after)
+		}
+		rest = strings.TrimLeft(after, " \t")
+	}
+	return fields, nil
+}
@@ -0,0 +1,216 @@
+package lirex
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAccessLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		combined bool
+		want     AccessLogEntry
+		err      bool
+	}{
+		{
+			name: "common",
+			line: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`,
+			want: AccessLogEntry{
+				RemoteAddr: "127.0.0.1", Ident: "-", User: "frank",
+				Time:   time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC),
+				Method: "GET", Path: "/apache_pb.gif", Protocol: "HTTP/1.0", Status: 200, Bytes: 2326,
+			},
+		},
+		{
+			name: "no bytes, no protocol",
+			line: `::1 - - [01/Jan/2024:00:00:00 +0000] "HEAD /" 304 -`,
+			want: AccessLogEntry{
+				RemoteAddr: "::1", Ident: "-", User: "-",
+				Time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Method: "HEAD", Path: "/", Status: 304, Bytes: -1,
+			},
+		},
+		{
+			name: "invalid request line",
+			line: `10.0.0.1 - - [01/Jan/2024:00:00:00 +0000] "-" 400 0`,
+			want: AccessLogEntry{
+				RemoteAddr: "10.0.0.1", Ident: "-", User: "-",
+				Time:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Status: 400, Bytes: 0,
+			},
+		},
+		{
+			name:     "combined with escaped quotes",
+			line:     `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0 \"test\""`,
+			combined: true,
+			want: AccessLogEntry{
+				RemoteAddr: "127.0.0.1", Ident: "-", User: "-",
+				Time:   time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC),
+				Method: "GET", Path: "/a.gif", Protocol: "HTTP/1.1", Status: 200, Bytes: 2326,
+				Referer: "http://example.com/", UserAgent: `Mozilla/5.0 "test"`,
+			},
+		},
+		{name: "missing status", line: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 2326`, err: true},
+		{name: "bad month", line: `127.0.0.1 - - [10/Foo/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 1`, err: true},
+		{name: "combined without agent", line: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 1`, combined: true, err: true},
+		{name: "empty", line: ``, err: true},
+	}
+	for _, test := range tests {
+		parse := ParseCommonLog
+		if test.combined {
+			parse = ParseCombinedLog
+		}
+		got, err := parse(test.line)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: got %+v, want an error", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !got.Time.Equal(test.want.Time) {
+			t.Errorf("%s: Time = %v, want %v", test.name, got.Time, test.want.Time)
+		}
+		got.Time, test.want.Time = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseSyslog(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		rfc  int
+		want SyslogMessage
+		err  bool
+	}{
+		{
+			name: "3164",
+			line: `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`,
+			rfc:  3164,
+			want: SyslogMessage{
+				Priority: 34, Facility: 4, Severity: 2,
+				Time:     time.Date(0, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname: "mymachine", AppName: "su", ProcID: "123",
+				Message: "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "3164 without priority and pid",
+			line: `Feb  5 07:00:01 host cron: job done`,
+			rfc:  3164,
+			want: SyslogMessage{
+				Priority: -1, Facility: -1, Severity: -1,
+				Time:     time.Date(0, 2, 5, 7, 0, 1, 0, time.UTC),
+				Hostname: "host", AppName: "cron", Message: "job done",
+			},
+		},
+		{name: "3164 priority out of range", line: `<192>Oct 11 22:14:15 host app: x`, rfc: 3164, err: true},
+		{
+			name: "5424 with structured data",
+			line: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high"] An application event`,
+			rfc:  5424,
+			want: SyslogMessage{
+				Priority: 165, Facility: 20, Severity: 5, Version: 1,
+				Time:     time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				Hostname: "mymachine.example.com", AppName: "evntslog", MsgID: "ID47",
+				StructuredData: map[string]map[string]string{
+					"exampleSDID@32473":     {"iut": "3", "eventSource": "Application", "eventID": "1011"},
+					"examplePriority@32473": {"class": "high"},
+				},
+				Message: "An application event",
+			},
+		},
+		{
+			name: "5424 nil values and escapes",
+			line: "<13>1 - - - - - [meta note=\"a \\\"b\\\" \\]\"] \ufeffhello",
+			rfc:  5424,
+			want: SyslogMessage{
+				Priority: 13, Facility: 1, Severity: 5, Version: 1,
+				StructuredData: map[string]map[string]string{"meta": {"note": `a "b" ]`}},
+				Message:        "hello",
+			},
+		},
+		{
+			name: "5424 without message",
+			line: `<14>1 2024-01-02T03:04:05+01:00 host app 42 - -`,
+			rfc:  5424,
+			want: SyslogMessage{
+				Priority: 14, Facility: 1, Severity: 6, Version: 1,
+				Time:     time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC),
+				Hostname: "host", AppName: "app", ProcID: "42",
+				StructuredData: map[string]map[string]string{},
+			},
+		},
+		{name: "5424 without version", line: `<14> 2024-01-02T03:04:05Z host app 42 - -`, rfc: 5424, err: true},
+		{name: "5424 given to 3164 parser", line: `<14>1 2024-01-02T03:04:05Z host app 42 - -`, rfc: 3164, err: true},
+	}
+	for _, test := range tests {
+		parse := ParseSyslog3164
+		if test.rfc == 5424 {
+			parse = ParseSyslog5424
+		}
+		got, err := parse(test.line)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: got %+v, want an error", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !got.Time.Equal(test.want.Time) {
+			t.Errorf("%s: Time = %v, want %v", test.name, got.Time, test.want.Time)
+		}
+		got.Time, test.want.Time = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		line string
+		want []LogfmtField
+		err  bool
+	}{
+		{
+			line: `level=info msg="user logged in" user_id=42 duration=1.5ms`,
+			want: []LogfmtField{{"level", "info"}, {"msg", "user logged in"}, {"user_id", "42"}, {"duration", "1.5ms"}},
+		},
+		{
+			line: `  debug empty= quoted="a \"b\" \\ c"  `,
+			want: []LogfmtField{{"debug", ""}, {"empty", ""}, {"quoted", `a "b" \ c`}},
+		},
+		{line: ``, want: []LogfmtField{}},
+		{line: `msg="unterminated`, err: true},
+		{line: `a=1"b"`, err: true},
+		{line: `="no key"`, err: true},
+	}
+	for _, test := range tests {
+		got, err := ParseLogfmt(test.line)
+		if test.err {
+			if err == nil {
+				t.Errorf("ParseLogfmt(%q) = %v, want an error", test.line, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLogfmt(%q): %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseLogfmt(%q) = %v, want %v", test.line, got, test.want)
+		}
+	}
+}
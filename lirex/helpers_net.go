@@ -0,0 +1,134 @@
+package lirex
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+func octet() Node { return digitRange(0, 255, 0) }
+func ipv4(capture bool) Node {
+	if !capture {
+		return Group(octet(), Group(Lit("."), octet()).Exactly(3))
+	}
+	// Word boundaries keep "256.1.1.1" or "1.2.3.4567" from matching a truncated address.
+	return Capture("IPv4",
+		WordBoundary,
+		Capture("IPv4_octet1", octet()),
+		Lit("."),
+		Capture("IPv4_octet2", octet()),
+		Lit("."),
+		Capture("IPv4_octet3", octet()),
+		Lit("."),
+		Capture("IPv4_octet4", octet()),
+		WordBoundary,
+	)
+}
+func ipv6() Node {
+	h := HexDigit.Between(1, 4)
+	hc := Group(h, Lit(":"))
+	ch := Group(Lit(":"), h)
+
+	// Go regexp is leftmost-first: forms consuming more input must come before the ones they prefix.
+	alts := []Node{
+		Seq(hc.Exactly(7), h),
+		Seq(hc.Exactly(6), ipv4(false)),
+	}
+	// IPv4 tail: the six hex groups before it compressed with "::" like the pure-hex forms below.
+	// They come first so "1::2:3.4.5.6" is not cut short at "1::2:3".
+	for k := uint(1); k <= 4; k++ {
+		alts = append(alts, Seq(hc.Between(1, k), Lit(":"), hc.Between(0, 5-k), ipv4(false)))
+	}
+	alts = append(alts,
+		Seq(hc.Exactly(5), Lit(":"), ipv4(false)),
+		Seq(Lit("::"), hc.Between(0, 5), ipv4(false)),
+	)
+	for k := uint(1); k <= 6; k++ {
+		alts = append(alts, Seq(hc.Between(1, k), ch.Between(1, 7-k)))
+	}
+	alts = append(alts,
+		Seq(hc.Between(1, 7), Lit(":")),
+		Seq(Lit(":"), Or(ch.Between(1, 7), Lit(":"))),
+	)
+	return Or(alts...)
+}
+func ipv4CIDR() CaptureNode {
+	return Capture("IPv4CIDR",
+		Capture("IPv4CIDR_addr", ipv4(false)),
+		Lit("/"),
+		Capture("IPv4CIDR_prefix", digitRange(0, 32, 0)),
+		WordBoundary,
+	)
+}
+func ipv6CIDR() CaptureNode {
+	return Capture("IPv6CIDR",
+		Capture("IPv6CIDR_addr", ipv6()),
+		Lit("/"),
+		Capture("IPv6CIDR_prefix", digitRange(0, 128, 0)),
+		WordBoundary,
+	)
+}
+func mac() CaptureNode {
+	hex2 := HexDigit.Exactly(2)
+	return Capture("MAC",
+		Or(
+			Seq(hex2, Group(Lit(":"), hex2).Exactly(5)),
+			Seq(hex2, Group(Lit("-"), hex2).Exactly(5)),
+			Seq(HexDigit.Exactly(4), Group(Lit("."), HexDigit.Exactly(4)).Exactly(2)),
+		),
+	)
+}
+func hostPort() CaptureNode {
+	label := Seq(
+		LatinDigit,
+		Group(CharClass(LatinDigit, Lit("-")).ZeroOrMore(), LatinDigit).Optional(),
+	)
+	return Capture("HostPort",
+		Capture("HostPort_host",
+			Or(
+				Group(Lit("["), ipv6(), Lit("]")),
+				ipv4(false),
+				domain(false),
+				label,
+			),
+		),
+		Lit(":"),
+		Capture("HostPort_port", digitRange(0, 65535, 0)),
+		WordBoundary,
+	)
+}
+
+// Parses the IPv4 and IPv6 captures returned by FindCaptures (IPv4 first).
+func CapturedAddrs(captures map[string][]string) ([]netip.Addr, error) {
+	addrs := []netip.Addr{}
+	for _, name := range []string{"IPv4", "IPv6"} {
+		for _, value := range captures[name] {
+			if value == "" {
+				continue
+			}
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("Lirex: %s capture '%s': %w", name, value, err)
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+// Parses the IPv4CIDR and IPv6CIDR captures returned by FindCaptures (IPv4 first).
+func CapturedPrefixes(captures map[string][]string) ([]netip.Prefix, error) {
+	prefixes := []netip.Prefix{}
+	for _, name := range []string{"IPv4CIDR", "IPv6CIDR"} {
+		for _, value := range captures[name] {
+			if value == "" {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("Lirex: %s capture '%s': %w", name, value, err)
+			}
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,109 @@
+package lirex
+
+import "math"
+
+func awsAccessKeyID() CaptureNode {
+	return Capture("AWSAccessKeyID",
+		WordBoundary,
+		words([]string{"AKIA", "ASIA", "ABIA", "ACCA", "AGPA", "AIDA", "AIPA", "ANPA", "ANVA", "APKA", "AROA"}),
+		CharClass(UpperLatin, Digit).Exactly(16),
+		WordBoundary,
+	)
+}
+
+// The ends check for the boundary that fits the end character, so 40 characters inside a longer
+// alphanumeric run (a SHA-256, a longer token) don't match.
+func awsSecretKey() CaptureNode {
+	slashPlus := CharClass(Lit("/+"))
+	return Capture("AWSSecretKey",
+		Or(Seq(WordBoundary, LatinDigit), Seq(NonWordBoundary, slashPlus)),
+		CharClass(LatinDigit, Lit("/+")).Exactly(38),
+		Or(Seq(LatinDigit, WordBoundary), Seq(slashPlus, NonWordBoundary)),
+	)
+}
+func gitHubToken() CaptureNode {
+	return Capture("GitHubToken",
+		WordBoundary,
+		Or(
+			Seq(Lit("gh"), CharClass(Lit("pousr")), Lit("_"), LatinDigit.Exactly(36)),
+			Seq(Lit("github_pat_"), WordChar.Exactly(82)),
+		),
+		WordBoundary,
+	)
+}
+func gitLabToken() CaptureNode {
+	return Capture("GitLabToken", WordBoundary, Lit("glpat-"), CharClass(WordChar, Lit("-")).AtLeast(20))
+}
+func slackToken() CaptureNode {
+	return Capture("SlackToken",
+		WordBoundary,
+		Lit("xox"),
+		CharClass(Lit("abposr")),
+		Lit("-"),
+		CharClass(LatinDigit, Lit("-")).AtLeast(10),
+	)
+}
+
+// Header and payload are base64url JSON objects, so both start with "eyJ" ({"). The signature is
+// empty for unsecured ("alg": "none") tokens.
+func jwt() CaptureNode {
+	segment := CharClass(WordChar, Lit("-"))
+	return Capture("JWT",
+		WordBoundary,
+		Capture("JWT_header", Lit("eyJ"), segment.ZeroOrMore()),
+		Lit("."),
+		Capture("JWT_payload", Lit("eyJ"), segment.ZeroOrMore()),
+		Lit("."),
+		Capture("JWT_signature", segment.ZeroOrMore()),
+	)
+}
+
+// The body stops at the first "--", so consecutive keys are matched separately.
+func pemPrivateKey() CaptureNode {
+	keyType := Group(UpperLatin.AtLeast(1), Lit(" ")).ZeroOrMore()
+	return Capture("PEMPrivateKey",
+		Lit("-----BEGIN "),
+		Capture("PEMPrivateKey_type", keyType),
+		Lit("PRIVATE KEY-----"),
+		Or(NotCharClass(Lit("-")), Seq(Lit("-"), NotCharClass(Lit("-")))).ZeroOrMore(),
+		Lit("-----END "),
+		keyType,
+		Lit("PRIVATE KEY-----"),
+	)
+}
+func highEntropyString() CaptureNode {
+	return Capture("HighEntropyString",
+		CharClass(LatinDigit, Lit("+/_-")).AtLeast(20),
+		Lit("=").Between(0, 2),
+	)
+}
+
+// Shannon entropy of s in bits per character.
+func ShannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, c := range s {
+		counts[c]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Returns a copy of the helper whose Valid() additionally requires the match to have
+// at least the given Shannon entropy (bits per character). Cuts false positives on
+// secret-like shapes such as "AAAAAAAAAAAAAAAAAAAA".
+func (node HelperNode) MinEntropy(bits float64) HelperNode {
+	validate := node.validate
+	node.validate = func(match string) bool {
+		if validate != nil && !validate(match) {
+			return false
+		}
+		return ShannonEntropy(match) >= bits
+	}
+	return node
+}
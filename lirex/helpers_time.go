@@ -0,0 +1,243 @@
+package lirex
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TIME LAYOUT ----------------------------------------------------------------------------
+type TimeLayoutNode struct {
+	layout string
+}
+
+// Matches text formatted with a Go reference layout, e.g. TimeLayout(time.RFC3339).
+// Captures: Time (whole match), Time_year, Time_month, Time_day, Time_yearDay, Time_weekday,
+// Time_hour, Time_minute, Time_second, Time_fraction, Time_ampm, Time_zone.
+func TimeLayout(layout string) TimeLayoutNode {
+	return TimeLayoutNode{layout: layout}
+}
+
+// Parses a match of the node back into time.Time.
+func (node TimeLayoutNode) Parse(value string) (time.Time, error) {
+	return time.Parse(node.layout, value)
+}
+
+func (node TimeLayoutNode) compile(ctx *CompileContext) (string, error) {
+	exp, _, err := timeLayout(node.layout, "Time")
+	if err != nil {
+		return "", err
+	}
+	return exp.compile(ctx)
+}
+
+var (
+	longMonthNames  = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	shortMonthNames = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	longDayNames    = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	shortDayNames   = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+)
+
+type layoutChunk struct {
+	std       string
+	component string
+	node      Node
+}
+
+// Ordered like the checks in time.nextStdChunk: longer chunks sharing a prefix come first.
+var layoutChunks = []layoutChunk{
+	{"January", "month", words(longMonthNames)},
+	{"Jan", "month", words(shortMonthNames)},
+	{"Monday", "weekday", words(longDayNames)},
+	{"Mon", "weekday", words(shortDayNames)},
+	{"MST", "zone", UpperLatin.Between(3, 5)},
+	{"002", "yearDay", digitRange(1, 366, 3)},
+	{"01", "month", digitRange(1, 12, 2)},
+	{"02", "day", digitRange(1, 31, 2)},
+	{"03", "hour", digitRange(1, 12, 2)},
+	{"04", "minute", digitRange(0, 59, 2)},
+	{"05", "second", digitRange(0, 59, 2)},
+	{"06", "year", Digit.Exactly(2)},
+	{"15", "hour", digitRange(0, 23, 2)},
+	{"1", "month", digitRange(1, 12, 0)},
+	{"2006", "year", Digit.Exactly(4)},
+	{"2", "day", digitRange(1, 31, 0)},
+	{"__2", "yearDay", spacePadded(1, 366, 3)},
+	{"_2", "day", spacePadded(1, 31, 2)},
+	{"Z07:00:00", "zone", Or(Lit("Z"), zoneOffset(":", true))},
+	{"Z070000", "zone", Or(Lit("Z"), zoneOffset("", true))},
+	{"Z07:00", "zone", Or(Lit("Z"), zoneOffset(":", false))},
+	{"Z0700", "zone", Or(Lit("Z"), zoneOffset("", false))},
+	{"Z07", "zone", Or(Lit("Z"), Seq(CharClass(Lit("+-")), digitRange(0, 23, 2)))},
+	{"-07:00:00", "zone", zoneOffset(":", true)},
+	{"-070000", "zone", zoneOffset("", true)},
+	{"-07:00", "zone", zoneOffset(":", false)},
+	{"-0700", "zone", zoneOffset("", false)},
+	{"-07", "zone", Seq(CharClass(Lit("+-")), digitRange(0, 23, 2))},
+	{"PM", "ampm", Or(Lit("AM"), Lit("PM"))},
+	{"pm", "ampm", Or(Lit("am"), Lit("pm"))},
+	{"3", "hour", digitRange(1, 12, 0)},
+	{"4", "minute", digitRange(0, 59, 0)},
+	{"5", "second", digitRange(0, 59, 0)},
+}
+
+func words(list []string) OrNode {
+	nodes := make([]Node, len(list))
+	for i, word := range list {
+		nodes[i] = Lit(word)
+	}
+	return Or(nodes...)
+}
+func spacePadded(lo, hi uint, width int) Node {
+	alts := []Node{}
+	for digits := width; digits >= 1; digits-- {
+		from, to := max(lo, pow10(digits-1)), min(hi, pow10(digits)-1)
+		if digits == 1 {
+			from = lo
+		}
+		if from > to {
+			continue
+		}
+		var exp Node = digitRange(from, to, 0)
+		if pad := width - digits; pad > 0 {
+			exp = Seq(Lit(strings.Repeat(" ", pad)), exp)
+		}
+		alts = append(alts, exp)
+	}
+	return Or(alts...)
+}
+func zoneOffset(sep string, seconds bool) Node {
+	nodes := []Node{CharClass(Lit("+-")), digitRange(0, 23, 2)}
+	if sep != "" {
+		nodes = append(nodes, Lit(sep))
+	}
+	nodes = append(nodes, digitRange(0, 59, 2))
+	if seconds {
+		if sep != "" {
+			nodes = append(nodes, Lit(sep))
+		}
+		nodes = append(nodes, digitRange(0, 59, 2))
+	}
+	return Seq(nodes...)
+}
+
+// Fractional seconds: ".000"/",000" need exactly that many digits, ".999"/",999" accept up to that many.
+func fractionChunk(layout string) (int, bool) {
+	if len(layout) < 2 || (layout[0] != '.' && layout[0] != ',') || (layout[1] != '0' && layout[1] != '9') {
+		return 0, false
+	}
+	n := 1
+	for n < len(layout) && layout[n] == layout[1] {
+		n++
+	}
+	if n < len(layout) && layout[n] >= '0' && layout[n] <= '9' {
+		return 0, false
+	}
+	return n, true
+}
+
+// Builds the node for a Go reference layout. Every component becomes a capture named prefix_component;
+// the whole match is captured as prefix. Returns the capture names in order.
+func timeLayout(layout, prefix string) (Node, []string, error) {
+	if layout == "" {
+		return nil, nil, fmt.Errorf("Lirex Compile: TimeLayout: empty layout.")
+	}
+	groups := []string{prefix}
+	nodes := []Node{}
+	literal := ""
+	addCapture := func(component string, node Node) {
+		if literal != "" {
+			nodes = append(nodes, Lit(literal))
+			literal = ""
+		}
+		name := prefix + "_" + component
+		for _, group := range groups {
+			if group == name {
+				nodes = append(nodes, Group(Seq(node)))
+				return
+			}
+		}
+		groups = append(groups, name)
+		nodes = append(nodes, Capture(name, node))
+	}
+
+	for i := 0; i < len(layout); {
+		if n, ok := fractionChunk(layout[i:]); ok {
+			sep := Lit(layout[i : i+1])
+			if layout[i+1] == '0' {
+				addCapture("fraction", Seq(sep, Digit.Exactly(uint(n-1))))
+			} else {
+				addCapture("fraction", Group(sep, Digit.Between(1, uint(n-1))).Optional())
+			}
+			i += n
+			continue
+		}
+
+		matched := false
+		for _, chunk := range layoutChunks {
+			if !strings.HasPrefix(layout[i:], chunk.std) {
+				continue
+			}
+			// "_2006" is a literal underscore followed by the year, as in time.nextStdChunk.
+			if chunk.std == "_2" && strings.HasPrefix(layout[i:], "_2006") {
+				continue
+			}
+			addCapture(chunk.component, chunk.node)
+			i += len(chunk.std)
+			matched = true
+
+			// time.Parse accepts fractional seconds after the seconds field even if the layout omits them.
+			if chunk.component == "second" {
+				if _, ok := fractionChunk(layout[i:]); !ok {
+					addCapture("fraction", Group(CharClass(Lit(".,")), Digit.AtLeast(1)).Optional())
+				}
+			}
+			break
+		}
+		if !matched {
+			literal += layout[i : i+1]
+			i++
+		}
+	}
+	if literal != "" {
+		nodes = append(nodes, Lit(literal))
+	}
+	if len(groups) == 1 {
+		return nil, nil, fmt.Errorf("Lirex Compile: TimeLayout: layout '%s' has no time components.", layout)
+	}
+	return Capture(prefix, nodes...), groups, nil
+}
+
+func mustTimeLayout(layout, prefix string) HelperNode {
+	exp, groups, err := timeLayout(layout, prefix)
+	if err != nil {
+		panic(err)
+	}
+	return compile(exp, groups...)
+}
+
+func isoDate() CaptureNode {
+	return Capture("ISO8601Date",
+		Capture("ISO8601Date_year", Digit.Exactly(4)),
+		Lit("-"),
+		Capture("ISO8601Date_month", digitRange(1, 12, 2)),
+		Lit("-"),
+		Capture("ISO8601Date_day", digitRange(1, 31, 2)),
+	)
+}
+
+// Accepts what time.ParseDuration accepts, e.g. "1h30m", "-1.5h", "300ms".
+func duration() CaptureNode {
+	number := Or(
+		Group(Digit.AtLeast(1), Group(Lit("."), Digit.ZeroOrMore()).Optional()),
+		Group(Lit("."), Digit.AtLeast(1)),
+	)
+	unit := Or(Lit("ns"), Lit("us"), Lit("µs"), Lit("μs"), Lit("ms"), Lit("s"), Lit("m"), Lit("h"))
+	return Capture("Duration",
+		CharClass(Lit("+-")).Optional(),
+		Or(
+			Group(number, unit).AtLeast(1),
+			Lit("0"),
+		),
+	)
+}
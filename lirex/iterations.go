@@ -0,0 +1,105 @@
+package lirex
+
+import "fmt"
+
+// Hidden capture marking the span of the repeat being re-scanned.
+const iterationTag = "lirexIterations"
+
+type Iteration struct {
+	Value string
+	// Byte offsets into the searched string
+	Start, End int
+}
+
+// Go's regexp only keeps the last iteration of a repeated capture. For every match of tree in str,
+// FindIterations returns all iterations of the capture name (qualified, if inside a Namespace) made
+// by its innermost enclosing repeat, in order. The span of that repeat is re-scanned with the
+// repeat's child as a derived expression, greedily, one iteration after another.
+func FindIterations(tree ExpTreeNode, opts Options, name, str string) ([][]Iteration, error) {
+	path, namespace, found := findCaptureRepeat(tree, name)
+	if !found {
+		return nil, fmt.Errorf("Lirex Iterations: no capture '%s' inside a repeat.", name)
+	}
+
+	var child Node
+	marked := replaceAt(tree, path, func(repeat Node) Node {
+		child = childNodes(repeat)[0]
+		return Capture(iterationTag, repeat)
+	})
+	full, err := Exp(marked...).Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	tag := full.SubexpIndex(qualifiedName(namespace, iterationTag))
+
+	// The child is compiled within the same namespaces so its capture keeps the qualified name.
+	var iteration Node = child
+	for i := len(namespace) - 1; i >= 0; i-- {
+		iteration = Namespace(namespace[i], iteration)
+	}
+	iterationRe, err := Exp(iteration).Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	group := iterationRe.SubexpIndex(name)
+	anchored := Anchor(iterationRe)
+
+	result := [][]Iteration{}
+	for _, loc := range full.FindAllStringSubmatchIndex(str, -1) {
+		iterations := []Iteration{}
+		start, end := loc[2*tag], loc[2*tag+1]
+		for pos := start; start >= 0 && pos < end; {
+			sub := anchored.FindAt(str, pos, end)
+			if sub == nil || sub[1] == pos {
+				break
+			}
+			if sub[2*group] >= 0 {
+				iterations = append(iterations, Iteration{
+					Value: str[sub[2*group]:sub[2*group+1]],
+					Start: sub[2*group],
+					End:   sub[2*group+1],
+				})
+			}
+			pos = sub[1]
+		}
+		result = append(result, iterations)
+	}
+	return result, nil
+}
+
+// Walks to the capture, remembering the innermost repeat on the way and the namespaces around it.
+func findCaptureRepeat(tree []Node, name string) ([]int, []string, bool) {
+	var (
+		repeatPath      []int
+		repeatNamespace []string
+	)
+	var walk func(nodes []Node, path []int, namespace []string, inRepeat bool) bool
+	walk = func(nodes []Node, path []int, namespace []string, inRepeat bool) bool {
+		for i, node := range nodes {
+			nodePath := append(append([]int(nil), path...), i)
+			nodeNamespace := namespace
+			switch node := node.(type) {
+			case CaptureNode:
+				if qualifiedName(namespace, node.name) == name {
+					return inRepeat
+				}
+			case NamespaceNode:
+				nodeNamespace = append(append([]string(nil), namespace...), node.prefix)
+			case AtLeastRepeatNode, ExactlyRepeatNode, BetweenRepeatNode, OptionalRepeatNode:
+				outerPath, outerNamespace := repeatPath, repeatNamespace
+				repeatPath, repeatNamespace = nodePath, namespace
+				if walk(childNodes(node), nodePath, namespace, true) {
+					return true
+				}
+				repeatPath, repeatNamespace = outerPath, outerNamespace
+				continue
+			}
+			if walk(childNodes(node), nodePath, nodeNamespace, inRepeat) {
+				return true
+			}
+		}
+		return false
+	}
+	found := walk(tree, nil, nil, false)
+	return repeatPath, repeatNamespace, found
+}
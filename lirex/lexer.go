@@ -0,0 +1,106 @@
+package lirex
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+type TokenType string
+
+type LexRule struct {
+	tokenType TokenType
+	node      Node
+	skip      bool
+}
+
+// Emits a token of the given type for input matched by node.
+func TokenRule(tokenType TokenType, node Node) LexRule {
+	return LexRule{tokenType: tokenType, node: node}
+}
+
+// Consumes input matched by node without emitting a token, e.g. SkipRule(Whitespace.AtLeast(1)).
+func SkipRule(node Node) LexRule {
+	return LexRule{node: node, skip: true}
+}
+
+type Token struct {
+	Type  TokenType
+	Value string
+	// Byte offset into the input
+	Offset int
+	// 1-based, Column counts runes
+	Line, Column int
+}
+
+type LexError struct {
+	Offset, Line, Column int
+	Rune                 rune
+}
+
+func (err *LexError) Error() string {
+	return fmt.Sprintf("Lirex Lexer: unrecognized input %q at line %d, column %d (offset %d).", err.Rune, err.Line, err.Column, err.Offset)
+}
+
+type lexRule struct {
+	LexRule
+	re *AnchoredRegexp
+}
+
+type Lexer struct {
+	rules []lexRule
+}
+
+// Compiles every rule anchored at the current position; assertions still see the text before
+// it. At each position the rule with the longest match wins; on equal lengths the rule listed
+// first wins.
+func NewLexer(opts Options, rules ...LexRule) (*Lexer, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("Lirex Lexer: no rules.")
+	}
+	lexer := &Lexer{rules: make([]lexRule, len(rules))}
+	for i, rule := range rules {
+		re, err := Exp(rule.node).Compile(opts)
+		if err != nil {
+			return nil, fmt.Errorf("Lirex Lexer: rule %d (%s): %w", i, rule.tokenType, err)
+		}
+		anchored := Anchor(re)
+		// Leftmost-longest, so each rule reports its longest match rather than its first.
+		anchored.Longest()
+		lexer.rules[i] = lexRule{LexRule: rule, re: anchored}
+	}
+	return lexer, nil
+}
+
+// Splits input into tokens. On unrecognized input returns the tokens so far and a *LexError.
+func (lexer *Lexer) Tokenize(input string) ([]Token, error) {
+	tokens := []Token{}
+	line, column := 1, 1
+	for offset := 0; offset < len(input); {
+		best, bestLen := -1, 0
+		for i, rule := range lexer.rules {
+			loc := rule.re.FindAt(input, offset, len(input))
+			if loc != nil && loc[1]-offset > bestLen {
+				best, bestLen = i, loc[1]-offset
+			}
+		}
+		if best < 0 {
+			r, _ := utf8.DecodeRuneInString(input[offset:])
+			return tokens, &LexError{Offset: offset, Line: line, Column: column, Rune: r}
+		}
+
+		value := input[offset : offset+bestLen]
+		if rule := lexer.rules[best]; !rule.skip {
+			tokens = append(tokens, Token{Type: rule.tokenType, Value: value, Offset: offset, Line: line, Column: column})
+		}
+		for _, r := range value {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		offset += bestLen
+	}
+	return tokens, nil
+}
@@ -0,0 +1,138 @@
+package lirex
+
+import "fmt"
+
+// LIST ---------------------------------------------------------------------------------
+type ListNode struct {
+	item           Node
+	sep            Node
+	min            uint
+	max            uint
+	trailingSep    bool
+	spaceAroundSep bool
+}
+
+// Regex equivalent: item(?:sep item){min-1,max-1}, optional as a whole if min == 0.
+// max == 0 means no upper bound.
+func List(item Node, sep Node, min, max uint) ListNode {
+	return ListNode{item: item, sep: sep, min: min, max: max}
+}
+
+// Also accepts a separator after the last item, e.g. "a, b,".
+func (node ListNode) AllowTrailingSep() ListNode {
+	node.trailingSep = true
+	return node
+}
+
+// Also accepts whitespace around separators, e.g. "a , b".
+func (node ListNode) SpaceAroundSep() ListNode {
+	node.spaceAroundSep = true
+	return node
+}
+
+func (node ListNode) separator() Node {
+	if node.spaceAroundSep {
+		return Seq(Whitespace.ZeroOrMore(), node.sep, Whitespace.ZeroOrMore())
+	}
+	return node.sep
+}
+
+// The item is compiled once and its text repeated, so captures inside it keep their name
+// (Go allows duplicate group names; FindCaptures and FindMatch merge them).
+func (node ListNode) compile(ctx *CompileContext) (string, error) {
+	if node.max != 0 && node.min > node.max {
+		return "", fmt.Errorf("Lirex Compile: List(min=%d, max=%d): min > max", node.min, node.max)
+	}
+	if node.max == 1 && ctx.showWarnings {
+		fmt.Println("WARNING: List(..., max=1) => Could use the item directly.")
+	}
+	item, err := node.item.compile(ctx)
+	if err != nil {
+		return "", err
+	}
+	sep, err := node.separator().compile(ctx)
+	if err != nil {
+		return "", err
+	}
+	if item == "" || sep == "" {
+		return "", handleEmptyNode(node, ctx)
+	}
+
+	restMin := uint(0)
+	if node.min > 1 {
+		restMin = node.min - 1
+	}
+	q := ""
+	switch {
+	case node.max == 0 && restMin <= 1:
+		q = []string{"*", "+"}[restMin]
+	case node.max == 0:
+		q = fmt.Sprintf("{%d,}", restMin)
+	case node.max-1 == restMin:
+		q = fmt.Sprintf("{%d}", restMin)
+	case restMin == 0 && node.max == 2:
+		q = "?"
+	default:
+		q = fmt.Sprintf("{%d,%d}", restMin, node.max-1)
+	}
+
+	result := "(?:" + item + ")"
+	if q != "{0}" {
+		result += "(?:" + sep + "(?:" + item + "))" + q
+	}
+	if node.trailingSep {
+		result += "(?:" + sep + ")?"
+	}
+	if node.min == 0 {
+		return "(?:" + result + ")?", nil
+	}
+	return "(?:" + result + ")", nil
+}
+
+// For every match of the list in str, returns its items in order with their positions. Empty
+// matches, which a list with min == 0 finds between items, are skipped.
+func (node ListNode) FindItems(opts Options, str string) ([][]Iteration, error) {
+	list, err := Exp(node).Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	itemRe, err := Exp(node.item).Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	sepRe, err := Exp(node.separator()).Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+	item, sep := Anchor(itemRe), Anchor(sepRe)
+
+	result := [][]Iteration{}
+	for _, loc := range list.FindAllStringIndex(str, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		items := []Iteration{}
+		for pos, end := loc[0], loc[1]; pos < end; {
+			if len(items) > 0 {
+				sepLoc := sep.FindAt(str, pos, end)
+				if sepLoc == nil {
+					break
+				}
+				pos = sepLoc[1]
+			}
+			itemLoc := item.FindAt(str, pos, end)
+			if itemLoc == nil {
+				break
+			}
+			items = append(items, Iteration{Value: str[pos:itemLoc[1]], Start: pos, End: itemLoc[1]})
+			if itemLoc[1] == pos {
+				break
+			}
+			pos = itemLoc[1]
+		}
+		if len(items) > 0 {
+			result = append(result, items)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,184 @@
+package lirex
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NAMESPACE ------------------------------------------------------------------------------
+type NamespaceNode struct {
+	prefix   string
+	children []Node
+}
+
+// Prefixes every capture name inside with prefix + "_", including the captures of helpers,
+// so shared fragments and helpers can be reused side by side:
+//
+//	Namespace("billing", Capture("zip", ...)) captures "billing_zip"
+func Namespace(prefix string, nodes ...Node) NamespaceNode {
+	return NamespaceNode{prefix: prefix, children: nodes}
+}
+
+func (node NamespaceNode) compile(ctx *CompileContext) (string, error) {
+	if !validCaptureName(node.prefix) {
+		return "", fmt.Errorf("Lirex Compile: Namespace: invalid prefix '%s'.", node.prefix)
+	}
+	ctx.namespace = append(ctx.namespace, node.prefix)
+	defer func() { ctx.namespace = ctx.namespace[:len(ctx.namespace)-1] }()
+
+	childrenCompiled, err := compileNodes(node.children, ctx)
+	if err != nil {
+		return "", err
+	}
+	if childrenCompiled == "" {
+		return "", handleEmptyNode(node, ctx)
+	}
+	return childrenCompiled, nil
+}
+
+// Capture name as seen from outside all enclosing namespaces.
+func (ctx *CompileContext) qualify(name string) string {
+	return qualifiedName(ctx.namespace, name)
+}
+
+func qualifiedName(namespace []string, name string) string {
+	if len(namespace) == 0 {
+		return name
+	}
+	return strings.Join(namespace, "_") + "_" + name
+}
+
+// Rewrites the names of all named groups of a compiled pattern. Only the (?P<name> tokens are
+// touched: printing a parsed pattern back would spell out flags and could change its meaning
+// under the caller's Options.
+func renameCaptures(pattern string, rename func(string) string) (string, error) {
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && strings.HasPrefix(pattern[i:], `\Q`):
+			end := strings.Index(pattern[i:], `\E`)
+			if end < 0 {
+				end = len(pattern) - i
+			} else {
+				end += len(`\E`)
+			}
+			b.WriteString(pattern[i : i+end])
+			i += end - 1
+			continue
+		case c == '\\' && i+1 < len(pattern):
+			// Escaped bytes never start a group or a class; multibyte runes can't contain '\\'.
+			b.WriteString(pattern[i : i+2])
+			i++
+			continue
+		case inClass:
+			// "[:alpha:]" ends at its ":]", not at the first ']'.
+			if strings.HasPrefix(pattern[i:], "[:") {
+				if end := strings.Index(pattern[i+2:], ":]"); end >= 0 {
+					b.WriteString(pattern[i : i+2+end+2])
+					i += 2 + end + 1
+					continue
+				}
+			}
+			inClass = c != ']'
+		case c == '[':
+			// A ']' right after "[" or "[^" is a literal, not the end of the class.
+			end := i + 1
+			if end < len(pattern) && pattern[end] == '^' {
+				end++
+			}
+			if end < len(pattern) && pattern[end] == ']' {
+				end++
+			}
+			b.WriteString(pattern[i:end])
+			i = end - 1
+			inClass = true
+			continue
+		case c == '(':
+			prefix := ""
+			for _, p := range []string{"(?P<", "(?<"} {
+				if strings.HasPrefix(pattern[i:], p) {
+					prefix = p
+				}
+			}
+			if prefix == "" {
+				break
+			}
+			end := strings.IndexByte(pattern[i:], '>')
+			if end < 0 {
+				return "", fmt.Errorf("Lirex Compile: unterminated capture name in %q.", pattern)
+			}
+			b.WriteString(prefix + rename(pattern[i+len(prefix):i+end]) + ">")
+			i += end
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+// MATCH --------------------------------------------------------------------------------
+// Named captures of one match, readable fully qualified or through Scope().
+type Match struct {
+	values map[string]string
+	scope  string
+}
+
+// Returns the named captures of the first match of re in str. Groups that didn't
+// participate are absent; of several groups sharing a name, the participating one is kept.
+func FindMatch(re *regexp.Regexp, str string) (Match, bool) {
+	loc := re.FindStringSubmatchIndex(str)
+	if loc == nil {
+		return Match{}, false
+	}
+	return newMatch(re, str, loc), true
+}
+
+// Like FindMatch for up to n (all if n < 0) matches.
+func FindAllMatches(re *regexp.Regexp, str string, n int) []Match {
+	matches := []Match{}
+	for _, loc := range re.FindAllStringSubmatchIndex(str, n) {
+		matches = append(matches, newMatch(re, str, loc))
+	}
+	return matches
+}
+
+func newMatch(re *regexp.Regexp, str string, loc []int) Match {
+	values := map[string]string{}
+	for i, name := range re.SubexpNames() {
+		if name == "" || loc[2*i] < 0 {
+			continue
+		}
+		if _, exists := values[name]; !exists {
+			values[name] = str[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return Match{values: values}
+}
+
+// Narrows the match to captures under prefix: m.Scope("billing").Get("zip") reads "billing_zip".
+// Scopes nest: m.Scope("order").Scope("billing") reads "order_billing_*".
+func (m Match) Scope(prefix string) Match {
+	m.scope = m.qualify(prefix)
+	return m
+}
+
+// Returns the capture value, or "" if it didn't participate.
+func (m Match) Get(name string) string {
+	return m.values[m.qualify(name)]
+}
+
+// Returns the capture value and whether it participated in the match.
+func (m Match) Lookup(name string) (string, bool) {
+	value, ok := m.values[m.qualify(name)]
+	return value, ok
+}
+
+func (m Match) qualify(name string) string {
+	if m.scope == "" {
+		return name
+	}
+	return m.scope + "_" + name
+}
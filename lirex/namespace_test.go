@@ -0,0 +1,40 @@
+package lirex
+
+import "testing"
+
+func TestRenameCaptures(t *testing.T) {
+	rename := func(name string) string { return "ns_" + name }
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+		err     bool
+	}{
+		{"P syntax", `(?P<a>\d)-(?P<b>\d)`, `(?P<ns_a>\d)-(?P<ns_b>\d)`, false},
+		{"angle syntax", `(?<a>\d)`, `(?<ns_a>\d)`, false},
+		{"nested", `(?P<a>(?P<b>x)y)`, `(?P<ns_a>(?P<ns_b>x)y)`, false},
+		{"other groups", `(?:a)(?i:b)(c)`, `(?:a)(?i:b)(c)`, false},
+		{"escaped paren", `\(?P<a>x`, `\(?P<a>x`, false},
+		{"escaped backslash", `\\(?P<a>x)`, `\\(?P<ns_a>x)`, false},
+		{"quoted", `\Q(?P<a>\E(?P<b>x)`, `\Q(?P<a>\E(?P<ns_b>x)`, false},
+		{"unterminated quote", `(?P<a>x)\Q(?P<b>`, `(?P<ns_a>x)\Q(?P<b>`, false},
+		{"class", `[(?P<a>](?P<b>x)`, `[(?P<a>](?P<ns_b>x)`, false},
+		{"class with leading ]", `[]()](?P<a>x)`, `[]()](?P<ns_a>x)`, false},
+		{"negated class with leading ]", `[^]()](?P<a>x)`, `[^]()](?P<ns_a>x)`, false},
+		{"class with escaped ]", `[\](](?P<a>x)`, `[\](](?P<ns_a>x)`, false},
+		{"POSIX class", `[[:alpha:](](?P<a>x)`, `[[:alpha:](](?P<ns_a>x)`, false},
+		{"unterminated name", `(?P<a`, "", true},
+	}
+	for _, test := range tests {
+		got, err := renameCaptures(test.pattern, rename)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: renameCaptures(%q) = %q, want an error", test.name, test.pattern, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%s: renameCaptures(%q) = %q, %v, want %q", test.name, test.pattern, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package lirex
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NUMBERS ------------------------------------------------------------------------------
+type Sign uint8
+
+const (
+	// No sign accepted
+	Unsigned Sign = iota
+	// Regex equivalent: [+\-]?
+	OptionalSign
+	// Regex equivalent: -?
+	OptionalMinus
+	// Regex equivalent: [+\-]
+	RequiredSign
+)
+
+type IntOptions struct {
+	Sign Sign
+	// Digit grouping separator, e.g. ',' for 1,234 or '.' for 1.234. 0 means no grouping.
+	ThousandsSep rune
+	// Digits per group. 0 means 3.
+	Grouping uint
+	// Currency symbols accepted before or after the number, e.g. "$", "€", "EUR"
+	Currency []string
+	// Capture name for the number; the symbol is captured as <Capture>_currency.
+	Capture string
+}
+
+type DecimalOptions struct {
+	IntOptions
+	// 0 means '.'
+	DecimalSep rune
+	// Fraction digits. MinFraction == 0 makes the fraction optional, MaxFraction == 0 means no limit.
+	// Float() ignores both.
+	MinFraction uint
+	MaxFraction uint
+}
+
+type numberKind uint8
+
+const (
+	integerKind numberKind = iota
+	decimalKind
+	floatKind
+)
+
+type NumberNode struct {
+	kind numberKind
+	opts DecimalOptions
+}
+
+// Regex equivalent (ThousandsSep ','): (?:\d{1,3}(?:,\d{3})+|\d+)
+func Integer(opts IntOptions) NumberNode {
+	return NumberNode{kind: integerKind, opts: DecimalOptions{IntOptions: opts}}
+}
+
+// Regex equivalent (MinFraction 2, MaxFraction 2): \d+\.\d{2}
+func Decimal(opts DecimalOptions) NumberNode {
+	return NumberNode{kind: decimalKind, opts: opts}
+}
+
+// Regex equivalent: (?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+\-]?\d+)?
+func Float(opts DecimalOptions) NumberNode {
+	return NumberNode{kind: floatKind, opts: opts}
+}
+
+func (node NumberNode) decimalSep() rune {
+	if node.opts.DecimalSep == 0 {
+		return '.'
+	}
+	return node.opts.DecimalSep
+}
+
+// Digits repeated min..max times; max == 0 means no upper bound.
+func digits(min, max uint) Node {
+	switch {
+	case max == 0:
+		return Digit.AtLeast(min)
+	case min == max:
+		return Digit.Exactly(min)
+	}
+	return Digit.Between(min, max)
+}
+
+func (node NumberNode) number() (Node, error) {
+	opts := node.opts
+	if opts.Sign > RequiredSign {
+		return nil, fmt.Errorf("Lirex Compile: Number: unknown Sign %d.", opts.Sign)
+	}
+	if opts.ThousandsSep != 0 && opts.ThousandsSep == node.decimalSep() && node.kind != integerKind {
+		return nil, fmt.Errorf("Lirex Compile: Number: thousands and decimal separator are both %q.", opts.ThousandsSep)
+	}
+	if opts.MaxFraction != 0 && opts.MinFraction > opts.MaxFraction {
+		return nil, fmt.Errorf("Lirex Compile: Decimal(MinFraction=%d, MaxFraction=%d): min > max", opts.MinFraction, opts.MaxFraction)
+	}
+
+	plusMinus := CharClass(Lit("+-"))
+	nodes := []Node{}
+	switch opts.Sign {
+	case OptionalSign:
+		nodes = append(nodes, plusMinus.Optional())
+	case OptionalMinus:
+		nodes = append(nodes, Lit("-").Optional())
+	case RequiredSign:
+		nodes = append(nodes, plusMinus)
+	}
+	integer := digits(1, 0)
+	if opts.ThousandsSep != 0 {
+		grouping := opts.Grouping
+		if grouping == 0 {
+			grouping = 3
+		}
+		group := Group(Seq(Lit(string(opts.ThousandsSep)), digits(grouping, grouping)))
+		integer = Or(Seq(digits(1, grouping), group.AtLeast(1)), digits(1, 0))
+	}
+	point := Lit(string(node.decimalSep()))
+
+	switch node.kind {
+	case decimalKind:
+		fraction := Group(Seq(point, digits(max(opts.MinFraction, 1), opts.MaxFraction)))
+		if opts.MinFraction == 0 {
+			return Seq(append(nodes, integer, fraction.Optional())...), nil
+		}
+		return Seq(append(nodes, integer, fraction)...), nil
+	case floatKind:
+		mantissa := Or(
+			Seq(integer, Group(Seq(point, Digit.ZeroOrMore())).Optional()),
+			Seq(point, digits(1, 0)),
+		)
+		exponent := Group(Seq(CharClass(Lit("eE")), plusMinus.Optional(), digits(1, 0)))
+		return Seq(append(nodes, mantissa, exponent.Optional())...), nil
+	}
+	return Seq(append(nodes, integer)...), nil
+}
+
+// The number is compiled once and repeated for the symbol-before and symbol-after forms.
+func (node NumberNode) compile(ctx *CompileContext) (string, error) {
+	number, err := node.number()
+	if err != nil {
+		return "", err
+	}
+	if node.opts.Capture != "" {
+		number = Capture(node.opts.Capture, number)
+	}
+	compiled, err := number.compile(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(node.opts.Currency) == 0 {
+		return "(?:" + compiled + ")", nil
+	}
+
+	symbols := make([]Node, len(node.opts.Currency))
+	for i, symbol := range node.opts.Currency {
+		if symbol == "" {
+			return "", fmt.Errorf("Lirex Compile: Number: empty currency symbol.")
+		}
+		symbols[i] = Lit(symbol)
+	}
+	currency := symbols[0]
+	if len(symbols) > 1 {
+		currency = Or(symbols...)
+	}
+	if node.opts.Capture != "" {
+		currency = Capture(node.opts.Capture+"_currency", currency)
+	}
+	symbol, err := currency.compile(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "(?:" + symbol + `\s?(?:` + compiled + ")|(?:" + compiled + `)(?:\s?` + symbol + ")?)", nil
+}
+
+// Plain Go syntax of a matched number: currency, spaces and grouping removed, '.' as decimal point.
+func (node NumberNode) normalize(s string) string {
+	s = strings.TrimSpace(s)
+	for _, symbol := range node.opts.Currency {
+		if strings.HasPrefix(s, symbol) {
+			s = strings.TrimSpace(strings.TrimPrefix(s, symbol))
+		}
+		if strings.HasSuffix(s, symbol) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, symbol))
+		}
+	}
+	if node.opts.ThousandsSep != 0 {
+		s = strings.ReplaceAll(s, string(node.opts.ThousandsSep), "")
+	}
+	return strings.ReplaceAll(s, string(node.decimalSep()), ".")
+}
+
+// Parses a match of the node, e.g. "€1.234,56" with Decimal(DecimalOptions{ThousandsSep: '.', DecimalSep: ','}).
+func (node NumberNode) ParseFloat(s string) (float64, error) {
+	value, err := strconv.ParseFloat(node.normalize(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("Lirex Number: %q is not a number: %w", s, err)
+	}
+	return value, nil
+}
+
+// Same as ParseFloat, without rounding.
+func (node NumberNode) ParseRat(s string) (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(node.normalize(s))
+	if !ok {
+		return nil, fmt.Errorf("Lirex Number: %q is not a number.", s)
+	}
+	return value, nil
+}
@@ -0,0 +1,97 @@
+package lirex
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Matches decimal numbers in [lo, hi]. With width > 0 every number is zero-padded to that width,
+// otherwise leading zeros are rejected. Longer numbers are tried first so unanchored matches aren't cut short.
+func digitRange(lo, hi uint, width int) Node {
+	var alts []Node
+	if width > 0 {
+		alts = rangeAlts(padDigits(lo, width), padDigits(hi, width))
+	} else {
+		for d := len(strconv.FormatUint(uint64(hi), 10)); d >= len(strconv.FormatUint(uint64(lo), 10)); d-- {
+			from, to := uint(0), uint(9)
+			if d > 1 {
+				from = pow10(d - 1)
+				to = pow10(d) - 1
+			}
+			from, to = max(from, lo), min(to, hi)
+			alts = append(alts, rangeAlts(strconv.FormatUint(uint64(from), 10), strconv.FormatUint(uint64(to), 10))...)
+		}
+	}
+	if len(alts) == 1 {
+		return alts[0]
+	}
+	return Or(alts...)
+}
+
+// Both bounds must have the same number of digits.
+func rangeAlts(a, b string) []Node {
+	if a == b {
+		return []Node{Lit(a)}
+	}
+	if a[0] == b[0] {
+		alts := rangeAlts(a[1:], b[1:])
+		for i, alt := range alts {
+			alts[i] = Seq(Lit(a[:1]), alt)
+		}
+		return alts
+	}
+	rest := len(a) - 1
+	if rest == 0 {
+		return []Node{digitClass(a[0], b[0])}
+	}
+
+	from, to := a[0], b[0]
+	alts := []Node{}
+	if strings.Trim(a[1:], "0") != "" {
+		for _, alt := range rangeAlts(a[1:], strings.Repeat("9", rest)) {
+			alts = append(alts, Seq(Lit(a[:1]), alt))
+		}
+		from++
+	}
+	highFull := strings.Trim(b[1:], "9") == ""
+	if !highFull {
+		to--
+	}
+	if from <= to {
+		var tail Node = Digit
+		if rest > 1 {
+			tail = Digit.Exactly(uint(rest))
+		}
+		alts = append(alts, Seq(digitClass(from, to), tail))
+	}
+	if !highFull {
+		for _, alt := range rangeAlts(strings.Repeat("0", rest), b[1:]) {
+			alts = append(alts, Seq(Lit(b[:1]), alt))
+		}
+	}
+	return alts
+}
+
+func digitClass(from, to byte) Node {
+	switch {
+	case from == to:
+		return Lit(string(from))
+	case from == '0' && to == '9':
+		return Digit
+	}
+	return RuneCharNode{value: "[" + string(from) + "-" + string(to) + "]"}
+}
+func padDigits(n uint, width int) string {
+	s := strconv.FormatUint(uint64(n), 10)
+	if len(s) < width {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	return s
+}
+func pow10(n int) uint {
+	result := uint(1)
+	for ; n > 0; n-- {
+		result *= 10
+	}
+	return result
+}
@@ -0,0 +1,407 @@
+package lirex
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// PARSE --------------------------------------------------------------------------------
+// Converts a Go regular expression into lirex nodes. Leading (?i), (?m) and (?s) flags become
+// Options. Unnamed groups become Group() (lirex only has named captures), and what lirex has no
+// node for (non-greedy repeats, flags in the middle of the pattern, \A, ...) is kept as UnsafeRaw().
+//
+//	Parse(`(?i)^(?P<user>\w+)@example\.com$`)
+//	=> Exp(LineStart, Capture("user", WordChar.AtLeast(1)), Lit("@example.com"), LineEnd), Options{CaseInsensitive: true}
+func Parse(pattern string) (ExpTreeNode, Options, error) {
+	opts := leadingFlags(pattern)
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, Options{}, fmt.Errorf("Lirex Parse: %w", err)
+	}
+	converter := regexConverter{opts: opts, written: writtenClasses(pattern)}
+	return Exp(converter.nodes(re)...), opts, nil
+}
+
+// Options set by (?flags) groups at the very start of pattern.
+func leadingFlags(pattern string) Options {
+	opts := Options{}
+	for strings.HasPrefix(pattern, "(?") {
+		end := strings.IndexByte(pattern, ')')
+		flags := pattern[2:max(end, 2)]
+		if end < 0 || flags == "" || strings.Trim(flags, "ims") != "" {
+			break
+		}
+		opts.CaseInsensitive = opts.CaseInsensitive || strings.Contains(flags, "i")
+		opts.Multiline = opts.Multiline || strings.Contains(flags, "m")
+		opts.DotMatchesNewline = opts.DotMatchesNewline || strings.Contains(flags, "s")
+		pattern = pattern[end+1:]
+	}
+	return opts
+}
+
+type regexConverter struct {
+	opts Options
+	// Char classes of the pattern as written, see writtenClasses
+	written map[string]writtenClass
+}
+
+// Nodes of re, with concatenations flattened and empty matches dropped.
+func (c regexConverter) nodes(re *syntax.Regexp) []Node {
+	if re.Op == syntax.OpEmptyMatch {
+		return nil
+	}
+	if re.Op != syntax.OpConcat {
+		return []Node{c.node(re)}
+	}
+	nodes := []Node{}
+	for _, sub := range re.Sub {
+		nodes = append(nodes, c.nodes(sub)...)
+	}
+	return nodes
+}
+
+// re as written, shielded from the global Options: syntax prints flags only where they are set.
+func (c regexConverter) raw(re *syntax.Regexp) RawNode {
+	return c.rawText(re.String())
+}
+
+func (c regexConverter) rawText(text string) RawNode {
+	flags := ""
+	if c.opts.CaseInsensitive {
+		flags += "i"
+	}
+	if c.opts.Multiline {
+		flags += "m"
+	}
+	if c.opts.DotMatchesNewline {
+		flags += "s"
+	}
+	if flags == "" {
+		return UnsafeRaw(text)
+	}
+	return UnsafeRaw("(?-" + flags + ":" + text + ")")
+}
+
+func (c regexConverter) node(re *syntax.Regexp) Node {
+	raw := c.raw(re)
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return UnsafeRaw(neverMatch)
+	case syntax.OpEmptyMatch:
+		return UnsafeRaw(`(?:)`)
+	case syntax.OpLiteral:
+		if foldable(re.Rune) && (re.Flags&syntax.FoldCase != 0) != c.opts.CaseInsensitive {
+			return raw
+		}
+		if re.Flags&syntax.FoldCase != 0 {
+			return Lit(strings.ToLower(string(re.Rune)))
+		}
+		return Lit(string(re.Rune))
+	case syntax.OpCharClass:
+		return c.charClass(re)
+	case syntax.OpAnyCharNotNL:
+		if c.opts.DotMatchesNewline {
+			return UnsafeRaw(`(?-s:.)`)
+		}
+		return AnyChar
+	case syntax.OpAnyChar:
+		if !c.opts.DotMatchesNewline {
+			return UnsafeRaw(`(?s:.)`)
+		}
+		return AnyChar
+	case syntax.OpBeginLine:
+		if !c.opts.Multiline {
+			return UnsafeRaw(`(?m:^)`)
+		}
+		return LineStart
+	case syntax.OpEndLine:
+		if !c.opts.Multiline {
+			return UnsafeRaw(`(?m:$)`)
+		}
+		return LineEnd
+	case syntax.OpBeginText:
+		if c.opts.Multiline {
+			return UnsafeRaw(`\A`)
+		}
+		return LineStart
+	case syntax.OpEndText:
+		if c.opts.Multiline || re.Flags&syntax.WasDollar == 0 {
+			return UnsafeRaw(`\z`)
+		}
+		return LineEnd
+	case syntax.OpWordBoundary:
+		return WordBoundary
+	case syntax.OpNoWordBoundary:
+		return NonWordBoundary
+	case syntax.OpCapture:
+		children := c.nodes(re.Sub[0])
+		if len(children) == 0 {
+			children = []Node{UnsafeRaw(`(?:)`)}
+		}
+		if re.Name == "" {
+			if or, ok := children[0].(OrNode); ok && len(children) == 1 {
+				return or
+			}
+			return Group(Seq(children...))
+		}
+		return Capture(re.Name, children...)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if re.Flags&syntax.NonGreedy != 0 {
+			return raw
+		}
+		return c.repeat(re)
+	case syntax.OpConcat:
+		return Seq(c.nodes(re)...)
+	case syntax.OpAlternate:
+		children := make([]Node, len(re.Sub))
+		for i, sub := range re.Sub {
+			children[i] = c.node(sub)
+		}
+		return Or(children...)
+	}
+	return raw
+}
+
+func (c regexConverter) repeat(re *syntax.Regexp) Node {
+	var child Repeatable
+	if node, ok := c.node(re.Sub[0]).(Repeatable); ok {
+		child = node
+	} else {
+		child = Group(Seq(c.nodes(re.Sub[0])...))
+	}
+	min, max := re.Min, re.Max
+	switch re.Op {
+	case syntax.OpStar:
+		min, max = 0, -1
+	case syntax.OpPlus:
+		min, max = 1, -1
+	case syntax.OpQuest:
+		min, max = 0, 1
+	}
+	switch {
+	case max < 0:
+		return atLeast(child, uint(min))
+	case min == max:
+		return exactly(child, uint(min))
+	case min == 0 && max == 1:
+		return optional(child)
+	}
+	return between(child, uint(min), uint(max))
+}
+
+func foldable(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.SimpleFold(r) != r {
+			return true
+		}
+	}
+	return false
+}
+
+// Char classes lirex has a name for.
+var namedClasses = []RuneCharNode{Digit, NonDigit, Whitespace, NonWhitespace, WordChar, NonWordChar, HexDigit, LatinDigit, Latin, LowerLatin, UpperLatin}
+
+// [lo, hi] pairs of a named class, as syntax.Parse sees it with or without (?i).
+func namedClassRanges(node RuneCharNode, foldCase bool) []rune {
+	flags := ""
+	if foldCase {
+		flags = "(?i)"
+	}
+	re, err := syntax.Parse(flags+node.value, syntax.Perl)
+	if err != nil || re.Op != syntax.OpCharClass {
+		return nil
+	}
+	return re.Rune
+}
+
+// Above this many ranges a class is kept as written (e.g. \p{L}).
+const maxClassRanges = 16
+
+func (c regexConverter) charClass(re *syntax.Regexp) Node {
+	if c.opts.CaseInsensitive && re.Flags&syntax.FoldCase == 0 {
+		return c.rawClass(re)
+	}
+	ranges := re.Rune
+	if c.opts.CaseInsensitive {
+		ranges = withoutFoldedRunes(ranges)
+	}
+	complement := complementRanges(ranges)
+	if len(ranges) == 0 {
+		return UnsafeRaw(neverMatch)
+	}
+	if len(complement) == 0 {
+		return UnsafeRaw(`[\x00-\x{10FFFF}]`)
+	}
+	for _, class := range namedClasses {
+		if equalRunes(ranges, namedClassRanges(class, c.opts.CaseInsensitive)) {
+			return class
+		}
+	}
+	if len(ranges) <= len(complement) && len(ranges) <= 2*maxClassRanges {
+		return CharClass(classItems(ranges)...)
+	}
+	if len(complement) <= 2*maxClassRanges {
+		return NotCharClass(classItems(complement)...)
+	}
+	return c.rawClass(re)
+}
+
+// re as written in the pattern if it is there: \pL would print as thousands of ranges.
+func (c regexConverter) rawClass(re *syntax.Regexp) RawNode {
+	class, ok := c.written[fmt.Sprint(re.Rune)]
+	switch {
+	case !ok:
+		return c.raw(re)
+	// Only (?i) changes what a class matches.
+	case class.foldCase == c.opts.CaseInsensitive:
+		return UnsafeRaw(class.text)
+	case class.foldCase:
+		return UnsafeRaw("(?i:" + class.text + ")")
+	}
+	return UnsafeRaw("(?-i:" + class.text + ")")
+}
+
+type writtenClass struct {
+	text     string
+	foldCase bool
+}
+
+// The \p{...} and [...] classes of pattern by the runes they match, alone and under (?i).
+func writtenClasses(pattern string) map[string]writtenClass {
+	classes := map[string]writtenClass{}
+	add := func(text string) {
+		for _, foldCase := range []bool{true, false} {
+			flags := ""
+			if foldCase {
+				flags = "(?i)"
+			}
+			if re, err := syntax.Parse(flags+text, syntax.Perl); err == nil && re.Op == syntax.OpCharClass {
+				classes[fmt.Sprint(re.Rune)] = writtenClass{text: text, foldCase: foldCase}
+			}
+		}
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], `\Q`):
+			end := strings.Index(pattern[i:], `\E`)
+			if end < 0 {
+				return classes
+			}
+			i += end + 1
+		case strings.HasPrefix(pattern[i:], `\p`) || strings.HasPrefix(pattern[i:], `\P`):
+			end := i + 3
+			if strings.HasPrefix(pattern[i+2:], "{") {
+				end = i + 2 + strings.IndexByte(pattern[i+2:], '}') + 1
+			}
+			if end > i+2 && end <= len(pattern) {
+				add(pattern[i:end])
+				i = end - 1
+			}
+		case pattern[i] == '\\':
+			i++
+		case pattern[i] == '[':
+			// A ']' right after "[" or "[^" is a literal, not the end of the class.
+			end := i + 1
+			if end < len(pattern) && pattern[end] == '^' {
+				end++
+			}
+			if end < len(pattern) && pattern[end] == ']' {
+				end++
+			}
+			for end < len(pattern) && pattern[end] != ']' {
+				if pattern[end] == '\\' {
+					end++
+				} else if strings.HasPrefix(pattern[end:], "[:") {
+					if close := strings.Index(pattern[end:], ":]"); close >= 0 {
+						end += close + 1
+					}
+				}
+				end++
+			}
+			if end >= len(pattern) {
+				return classes
+			}
+			add(pattern[i : end+1])
+			i = end
+		}
+	}
+	return classes
+}
+
+// Under (?i) a class lists every case of its runes; keeps one per rune with an ASCII lowercase
+// case (s for s, S and ſ), since (?i) adds the others back.
+func withoutFoldedRunes(ranges []rune) []rune {
+	contains := func(r rune) bool {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	asciiLower := func(r rune) bool { return 'a' <= r && r <= 'z' }
+	kept := []rune{}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo == hi && !asciiLower(lo) {
+			folded := false
+			for f := unicode.SimpleFold(lo); f != lo; f = unicode.SimpleFold(f) {
+				folded = folded || (asciiLower(f) && contains(f))
+			}
+			if folded {
+				continue
+			}
+		}
+		kept = append(kept, lo, hi)
+	}
+	return kept
+}
+
+func classItems(ranges []rune) []CharClassable {
+	items := []CharClassable{}
+	singles := ""
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		switch {
+		case lo == hi:
+			singles += string(lo)
+		case hi == lo+1:
+			singles += string(lo) + string(hi)
+		default:
+			items = append(items, CharRange(lo, hi))
+		}
+	}
+	if singles != "" {
+		items = append(items, Lit(singles))
+	}
+	return items
+}
+
+func complementRanges(ranges []rune) []rune {
+	complement := []rune{}
+	next := rune(0)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] > next {
+			complement = append(complement, next, ranges[i]-1)
+		}
+		next = ranges[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		complement = append(complement, next, unicode.MaxRune)
+	}
+	return complement
+}
+
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package lirex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QUOTED -------------------------------------------------------------------------------
+type QuoteOptions struct {
+	// Escape character, e.g. '\\' for C-style strings. 0 means no escapes.
+	Escape rune
+	// Whether the quoted text can span lines
+	AllowNewlines bool
+	// Two closing quotes stand for one, e.g. SQL 'it''s'
+	DoubledQuoteEscapes bool
+	// Capture name for the text between the quotes. Empty means no capture.
+	Capture string
+}
+
+type QuotedNode struct {
+	open  rune
+	close rune
+	opts  QuoteOptions
+}
+
+// Regex equivalent (open = close = ", Escape = \): "(?:\\.|[^"\\\n])*"
+func Quoted(open, close rune, opts QuoteOptions) QuotedNode {
+	return QuotedNode{open: open, close: close, opts: opts}
+}
+
+func (node QuotedNode) inner() Node {
+	excluded := []CharClassable{Lit(string(node.close))}
+	alternatives := []Node{}
+	if node.opts.Escape != 0 {
+		escaped := Node(NotCharClass(Newline))
+		if node.opts.AllowNewlines {
+			escaped = CharClass(Whitespace, NonWhitespace)
+		}
+		alternatives = append(alternatives, Seq(Lit(string(node.opts.Escape)), escaped))
+		excluded = append(excluded, Lit(string(node.opts.Escape)))
+	}
+	if node.opts.DoubledQuoteEscapes {
+		alternatives = append(alternatives, Lit(strings.Repeat(string(node.close), 2)))
+	}
+	if !node.opts.AllowNewlines {
+		excluded = append(excluded, Newline)
+	}
+	plain := NotCharClass(excluded...)
+	if len(alternatives) == 0 {
+		return plain.ZeroOrMore()
+	}
+	return Or(append(alternatives, plain)...).ZeroOrMore()
+}
+
+func (node QuotedNode) compile(ctx *CompileContext) (string, error) {
+	if node.open == 0 || node.close == 0 {
+		return "", fmt.Errorf("Lirex Compile: Quoted() needs both an opening and a closing rune.")
+	}
+	if node.opts.Escape == node.close {
+		return "", fmt.Errorf("Lirex Compile: Quoted() escape %q equals the closing quote; use DoubledQuoteEscapes.", node.opts.Escape)
+	}
+	var inner Node = node.inner()
+	if node.opts.Capture != "" {
+		inner = Capture(node.opts.Capture, inner)
+	}
+	return Seq(Lit(string(node.open)), inner, Lit(string(node.close))).compile(ctx)
+}
+
+// Resolves escapes in the text between the quotes (e.g. the Capture value).
+// With Escape '\\', \n, \t, \r and \0 become their control characters; any other
+// escaped character stands for itself.
+func (node QuotedNode) Unescape(inner string) string {
+	var b strings.Builder
+	runes := []rune(inner)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case node.opts.Escape != 0 && r == node.opts.Escape && i+1 < len(runes):
+			i++
+			r = runes[i]
+			if node.opts.Escape == '\\' {
+				switch r {
+				case 'n':
+					r = '\n'
+				case 't':
+					r = '\t'
+				case 'r':
+					r = '\r'
+				case '0':
+					r = 0
+				}
+			}
+		case node.opts.DoubledQuoteEscapes && r == node.close && i+1 < len(runes) && runes[i+1] == node.close:
+			i++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
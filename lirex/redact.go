@@ -0,0 +1,223 @@
+package lirex
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// REDACT STRATEGIES -------------------------------------------------------------------
+type RedactStrategy interface {
+	redact(name, match string) string
+}
+
+type maskStrategy struct {
+	mask rune
+	keep int
+}
+type hmacStrategy struct{ key []byte }
+type nameStrategy struct{}
+
+// Replaces every character of the match with mask.
+func MaskAll(mask rune) RedactStrategy { return maskStrategy{mask: mask} }
+
+// Replaces every character but the last n with mask, e.g. KeepLast(4, '*') on a card number.
+func KeepLast(n int, mask rune) RedactStrategy { return maskStrategy{mask: mask, keep: n} }
+
+// Replaces the match with the first 16 hex digits of its HMAC-SHA256 under key, so equal values
+// stay correlatable across log lines without being readable.
+func HMACHash(key []byte) RedactStrategy { return hmacStrategy{key: key} }
+
+// Replaces the match with the rule name in brackets, e.g. "[Email]".
+func CaptureName() RedactStrategy { return nameStrategy{} }
+
+func (s maskStrategy) redact(_, match string) string {
+	n := utf8.RuneCountInString(match)
+	keep := min(max(s.keep, 0), n)
+	if keep == 0 {
+		return strings.Repeat(string(s.mask), n)
+	}
+	tail := match
+	for i := 0; i < n-keep; i++ {
+		_, size := utf8.DecodeRuneInString(tail)
+		tail = tail[size:]
+	}
+	return strings.Repeat(string(s.mask), n-keep) + tail
+}
+func (s hmacStrategy) redact(_, match string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(match))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+func (nameStrategy) redact(name, _ string) string { return "[" + name + "]" }
+
+// REDACTOR ----------------------------------------------------------------------------
+type RedactRule struct {
+	// Used by CaptureName(). Defaults to the helper or capture name of Node, or "REDACTED".
+	Name     string
+	Node     Node
+	Strategy RedactStrategy
+	// Also redacts matches failing the helper's Valid() that no later rule accepts, instead of
+	// letting them through: for text that must go even when it is malformed.
+	RedactInvalid bool
+}
+
+type redactRule struct {
+	name     string
+	strategy RedactStrategy
+	// Index of the rule's group in the combined expression
+	group int
+	// Helper checks (checksums, entropy) deciding whether the rule claims a match
+	valid         func(string) bool
+	redactInvalid bool
+	// The rule alone, to offer it a span another rule's valid rejected
+	re *AnchoredRegexp
+}
+
+type Redactor struct {
+	re    *regexp.Regexp
+	rules []redactRule
+}
+
+// Compiles all rules into one expression so text is rewritten in a single pass.
+// Matches never overlap: the earliest match wins, and among matches starting at the
+// same position the rule listed first wins. A match failing its helper's Valid() goes to the
+// next rule matching the same span that accepts it, or else is left as is, unless its rule
+// sets RedactInvalid.
+func NewRedactor(opts Options, rules ...RedactRule) (*Redactor, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("Lirex Redactor: no rules.")
+	}
+	r := &Redactor{rules: make([]redactRule, len(rules))}
+	parts := make([]string, len(rules))
+	group := 1
+	for i, rule := range rules {
+		if rule.Strategy == nil {
+			return nil, fmt.Errorf("Lirex Redactor: rule %d has no strategy.", i)
+		}
+		re, err := Exp(rule.Node).Compile(opts)
+		if err != nil {
+			return nil, fmt.Errorf("Lirex Redactor: rule %d: %w", i, err)
+		}
+		name := rule.Name
+		if name == "" {
+			name = nodeName(rule.Node, "REDACTED")
+		}
+		r.rules[i] = redactRule{
+			name: name, strategy: rule.Strategy, group: group, re: Anchor(re), redactInvalid: rule.RedactInvalid,
+		}
+		r.rules[i].re.Longest()
+		if helper, ok := rule.Node.(HelperNode); ok {
+			r.rules[i].valid = helper.Valid
+		}
+		parts[i] = "(" + re.String() + ")"
+		group += 1 + re.NumSubexp()
+	}
+
+	re, err := regexp.Compile(strings.Join(parts, "|"))
+	if err != nil {
+		return nil, err
+	}
+	r.re = re
+	return r, nil
+}
+
+// Name of the outermost capture of a helper or capture node.
+func nodeName(node Node, fallback string) string {
+	switch node := node.(type) {
+	case HelperNode:
+		return node.name
+	case CaptureNode:
+		return node.name
+	}
+	return fallback
+}
+
+func (r *Redactor) String(s string) string {
+	return string(r.Bytes([]byte(s)))
+}
+func (r *Redactor) Bytes(b []byte) []byte {
+	matches := r.re.FindAllSubmatchIndex(b, -1)
+	if len(matches) == 0 {
+		return b
+	}
+	var out bytes.Buffer
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		rule, ok := r.matchRule(b, match)
+		if !ok {
+			continue
+		}
+		out.Write(b[last:start])
+		out.WriteString(rule.strategy.redact(rule.name, string(b[start:end])))
+		last = end
+	}
+	out.Write(b[last:])
+	return out.Bytes()
+}
+
+// Rule redacting a match: the first participating rule, unless its valid rejects the text and
+// a later rule matching the whole span accepts it. Not ok if no rule accepts it and the first
+// doesn't redact invalid matches.
+func (r *Redactor) matchRule(b []byte, match []int) (redactRule, bool) {
+	first := 0
+	for match[2*r.rules[first].group] < 0 {
+		first++
+	}
+	rule := r.rules[first]
+	value := string(b[match[0]:match[1]])
+	if rule.valid == nil || rule.valid(value) {
+		return rule, true
+	}
+	// The rune before the span goes along as left context for the assertions.
+	from := max(match[0]-utf8.UTFMax, 0)
+	span := string(b[from:match[1]])
+	for _, next := range r.rules[first+1:] {
+		loc := next.re.FindAt(span, match[0]-from, len(span))
+		if loc != nil && loc[1] == len(span) && (next.valid == nil || next.valid(value)) {
+			return next, true
+		}
+	}
+	return rule, rule.redactInvalid
+}
+
+// Wraps w so everything written is redacted line by line before reaching it, e.g. for log sinks.
+// Incomplete lines are buffered until a newline arrives or Close is called, so patterns spanning
+// several lines only match when those lines arrive in one Write. Close does not close w.
+func (r *Redactor) Writer(w io.Writer) io.WriteCloser {
+	return &redactWriter{redactor: r, w: w}
+}
+
+type redactWriter struct {
+	redactor *Redactor
+	w        io.Writer
+	buf      []byte
+}
+
+func (rw *redactWriter) Write(p []byte) (int, error) {
+	rw.buf = append(rw.buf, p...)
+	end := bytes.LastIndexByte(rw.buf, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	if _, err := rw.w.Write(rw.redactor.Bytes(rw.buf[:end+1])); err != nil {
+		return 0, err
+	}
+	rw.buf = append(rw.buf[:0], rw.buf[end+1:]...)
+	return len(p), nil
+}
+func (rw *redactWriter) Close() error {
+	if len(rw.buf) == 0 {
+		return nil
+	}
+	_, err := rw.w.Write(rw.redactor.Bytes(rw.buf))
+	rw.buf = rw.buf[:0]
+	return err
+}
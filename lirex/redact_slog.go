@@ -0,0 +1,102 @@
+package lirex
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+type RedactHandlerOptions struct {
+	// Attribute keys whose values are passed through untouched. An entry matches either the bare
+	// key or its dotted path through groups, e.g. "id" or "request.id".
+	AllowKeys []string
+}
+
+// slog.Handler middleware redacting the message and all string attribute values (including
+// values nested in groups and produced by slog.LogValuer) before passing records on. Other values
+// of kind Any (errors, fmt.Stringers, structs) are checked in their fmt.Sprint form and replaced by
+// it, redacted, when a rule matches; numbers, bools, times and durations pass as they are.
+type RedactHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+	allow    map[string]struct{}
+	// Groups opened with WithGroup, used for dotted allowlist paths
+	groups []string
+}
+
+func NewRedactHandler(next slog.Handler, redactor *Redactor, opts RedactHandlerOptions) *RedactHandler {
+	allow := make(map[string]struct{}, len(opts.AllowKeys))
+	for _, key := range opts.AllowKeys {
+		allow[key] = struct{}{}
+	}
+	return &RedactHandler{next: next, redactor: redactor, allow: allow}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+func (h *RedactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr, h.groups))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr, h.groups)
+	}
+	clone := *h
+	clone.next = h.next.WithAttrs(redacted)
+	return &clone
+}
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.groups = append(append([]string(nil), h.groups...), name)
+	return &clone
+}
+
+func (h *RedactHandler) redactAttr(attr slog.Attr, groups []string) slog.Attr {
+	if h.allowed(attr.Key, groups) {
+		return attr
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.String(value.String()))
+	case slog.KindAny:
+		text := fmt.Sprint(value.Any())
+		if redacted := h.redactor.String(text); redacted != text {
+			return slog.String(attr.Key, redacted)
+		}
+	case slog.KindGroup:
+		// Attributes of a group with an empty key are inlined into the parent.
+		if attr.Key != "" {
+			groups = append(append([]string(nil), groups...), attr.Key)
+		}
+		children := value.Group()
+		redacted := make([]any, len(children))
+		for i, child := range children {
+			redacted[i] = h.redactAttr(child, groups)
+		}
+		return slog.Group(attr.Key, redacted...)
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+func (h *RedactHandler) allowed(key string, groups []string) bool {
+	if _, ok := h.allow[key]; ok {
+		return true
+	}
+	if len(groups) == 0 {
+		return false
+	}
+	_, ok := h.allow[strings.Join(groups, ".")+"."+key]
+	return ok
+}
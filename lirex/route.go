@@ -0,0 +1,200 @@
+package lirex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ROUTE --------------------------------------------------------------------------------
+// Parameter type of route templates: the Node a value must match and its conversion.
+type ParamType struct {
+	Node  Node
+	Parse func(string) (any, error)
+}
+
+var (
+	paramTypesMu sync.RWMutex
+	paramTypes   = map[string]ParamType{
+		// One path segment
+		"string": {Node: NotCharClass(Lit("/")).AtLeast(1), Parse: func(s string) (any, error) { return s, nil }},
+		// Everything up to the end, slashes included (possibly empty)
+		"rest":  {Node: AnyChar.ZeroOrMore(), Parse: func(s string) (any, error) { return s, nil }},
+		"int":   {Node: Integer(IntOptions{Sign: OptionalMinus}), Parse: parsed(strconv.Atoi)},
+		"uint":  {Node: Digit.AtLeast(1), Parse: parsed(func(s string) (uint64, error) { return strconv.ParseUint(s, 10, 64) })},
+		"float": {Node: Float(DecimalOptions{IntOptions: IntOptions{Sign: OptionalMinus}}), Parse: parsed(func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })},
+		"slug": {
+			Node:  Seq(CharClass(LowerLatin, Digit).AtLeast(1), Group(Lit("-"), CharClass(LowerLatin, Digit).AtLeast(1)).ZeroOrMore()),
+			Parse: func(s string) (any, error) { return s, nil },
+		},
+		"uuid": {
+			Node:  Seq(HexDigit.Exactly(8), Lit("-"), HexDigit.Exactly(4), Lit("-"), HexDigit.Exactly(4), Lit("-"), HexDigit.Exactly(4), Lit("-"), HexDigit.Exactly(12)),
+			Parse: func(s string) (any, error) { return strings.ToLower(s), nil },
+		},
+	}
+)
+
+func parsed[T any](parse func(string) (T, error)) func(string) (any, error) {
+	return func(s string) (any, error) {
+		value, err := parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// Makes {param:name} available in route templates. Parse converts matched values; nil keeps them as strings.
+// Captures inside node are not renamed, so a type with captures can be used once per expression.
+func RegisterParamType(name string, node Node, parse func(string) (any, error)) error {
+	if !validCaptureName(name) {
+		return fmt.Errorf("Lirex Route: invalid param type name '%s'.", name)
+	}
+	if node == nil {
+		return fmt.Errorf("Lirex Route: param type '%s' has no node.", name)
+	}
+	if parse == nil {
+		parse = func(s string) (any, error) { return s, nil }
+	}
+	paramTypesMu.Lock()
+	defer paramTypesMu.Unlock()
+	if _, exists := paramTypes[name]; exists {
+		return fmt.Errorf("Lirex Route: param type '%s' is already registered.", name)
+	}
+	paramTypes[name] = ParamType{Node: node, Parse: parse}
+	return nil
+}
+
+func lookupParamType(name string) (ParamType, error) {
+	paramTypesMu.RLock()
+	defer paramTypesMu.RUnlock()
+	paramType, exists := paramTypes[name]
+	if !exists {
+		return ParamType{}, fmt.Errorf("Lirex Route: unknown param type '%s'.", name)
+	}
+	return paramType, nil
+}
+
+type RouteParam struct {
+	Name string
+	Type string
+}
+
+type routePart struct {
+	literal string
+	param   RouteParam
+}
+
+type RouteNode struct {
+	template string
+	parts    []routePart
+	err      error
+}
+
+// Path template with typed parameters: {name} is one segment, {name:type} uses a registered
+// param type (string, rest, int, uint, float, slug, uuid or custom).
+//
+//	Route("/users/{id:int}/files/{path:rest}") captures "id" and "path"
+func Route(template string) RouteNode {
+	node := RouteNode{template: template}
+	node.parts, node.err = parseRoute(template)
+	return node
+}
+
+func parseRoute(template string) ([]routePart, error) {
+	parts := []routePart{}
+	seen := map[string]struct{}{}
+	rest := template
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			parts = append(parts, routePart{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("Lirex Route: unexpected '}' in %q.", template)
+		}
+		if open > 0 {
+			parts = append(parts, routePart{literal: rest[:open]})
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] != '}' {
+			return nil, fmt.Errorf("Lirex Route: unclosed '{' in %q.", template)
+		}
+		name, typ, _ := strings.Cut(rest[open+1:open+1+end], ":")
+		if typ == "" {
+			typ = "string"
+		}
+		if !validCaptureName(name) {
+			return nil, fmt.Errorf("Lirex Route: invalid param name '%s' in %q.", name, template)
+		}
+		if _, exists := seen[name]; exists {
+			return nil, fmt.Errorf("Lirex Route: duplicate param '%s' in %q.", name, template)
+		}
+		seen[name] = struct{}{}
+		parts = append(parts, routePart{param: RouteParam{Name: name, Type: typ}})
+		rest = rest[open+1+end+1:]
+	}
+	return parts, nil
+}
+
+func (node RouteNode) compile(ctx *CompileContext) (string, error) {
+	if node.err != nil {
+		return "", node.err
+	}
+	nodes := []Node{}
+	for _, part := range node.parts {
+		if part.param.Name == "" {
+			nodes = append(nodes, Lit(part.literal))
+			continue
+		}
+		paramType, err := lookupParamType(part.param.Type)
+		if err != nil {
+			return "", err
+		}
+		nodes = append(nodes, Capture(part.param.Name, paramType.Node))
+	}
+	return Seq(nodes...).compile(ctx)
+}
+
+// The template the node was built from.
+func (node RouteNode) String() string {
+	return node.template
+}
+
+// Parameters in template order.
+func (node RouteNode) Params() []RouteParam {
+	params := []RouteParam{}
+	for _, part := range node.parts {
+		if part.param.Name != "" {
+			params = append(params, part.param)
+		}
+	}
+	return params
+}
+
+// Converts the params of a match to their types, e.g. {"id": 42, "path": "a/b.txt"}.
+// Use m.Scope(prefix) for a route inside a Namespace().
+func (node RouteNode) Values(m Match) (map[string]any, error) {
+	if node.err != nil {
+		return nil, node.err
+	}
+	values := map[string]any{}
+	for _, param := range node.Params() {
+		paramType, err := lookupParamType(param.Type)
+		if err != nil {
+			return nil, err
+		}
+		raw, ok := m.Lookup(param.Name)
+		if !ok {
+			continue
+		}
+		value, err := paramType.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Lirex Route: param '%s' (%s): %w", param.Name, param.Type, err)
+		}
+		values[param.Name] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,155 @@
+package lirex
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tag capture wrapped around every pattern of a Set, named setTagPrefix + pattern name.
+const setTagPrefix = "lirexSet_"
+
+type SetMatch struct {
+	Name       string
+	Start, End int
+	// Named captures of the matched pattern. Captures that didn't participate are omitted.
+	Captures map[string]string
+}
+
+type setPattern struct {
+	name string
+	// Index of the tag capture in the combined expression
+	tag int
+	// Indexes of the pattern's own named captures relative to the tag, and their names
+	groups []int
+	names  []string
+}
+
+// Many named expressions compiled into one program, so a line is scanned once
+// no matter how many patterns are tried.
+type Set struct {
+	re *regexp.Regexp
+	// re searched for from a given position, its match is group 1
+	from     *AnchoredRegexp
+	patterns []setPattern
+}
+
+// Compiles every expression separately (helpers and capture names may repeat across patterns),
+// wraps it in a tag capture named after the pattern and joins them into one alternation.
+// Pattern names follow the Capture name rules. When matches of several patterns overlap, FindAll
+// reports the earliest, and of those starting at the same position the one whose name sorts first.
+// opts apply to every pattern, as in NewLexer and NewRedactor; a Set of case-insensitive or
+// multiline patterns needs them, since a pattern can't carry Options of its own.
+func NewSet(patterns map[string]ExpTreeNode, opts Options) (*Set, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("Lirex Set: no patterns.")
+	}
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	set := &Set{}
+	parts := make([]string, len(names))
+	group := 1
+	for i, name := range names {
+		if !validCaptureName(name) {
+			return nil, fmt.Errorf("Lirex Set: invalid pattern name '%s'.", name)
+		}
+		tree := patterns[name]
+		re, err := Exp(Capture(setTagPrefix+name, tree...)).Compile(opts)
+		if err != nil {
+			return nil, fmt.Errorf("Lirex Set: pattern '%s': %w", name, err)
+		}
+		pattern := setPattern{name: name, tag: group}
+		for j, subName := range re.SubexpNames()[2:] {
+			if subName != "" {
+				pattern.groups = append(pattern.groups, 1+j)
+				pattern.names = append(pattern.names, subName)
+			}
+		}
+		set.patterns = append(set.patterns, pattern)
+		// Flags like (?i) stay scoped to their own branch.
+		parts[i] = "(?:" + re.String() + ")"
+		group += re.NumSubexp()
+	}
+
+	re, err := regexp.Compile(strings.Join(parts, "|"))
+	if err != nil {
+		return nil, err
+	}
+	set.re = re
+	set.from = Anchor(regexp.MustCompile(`(?s:.)*?(` + re.String() + `)`))
+	return set, nil
+}
+
+// Returns every pattern that matches in str with the position of its first match, in order of
+// appearance. The combined expression is restarted one rune after the start of each match rather
+// than at its end, so matches overlapping other patterns' are found too. Of patterns matching at
+// the same position, only the one whose name sorts first is reported there.
+func (set *Set) Match(str string) []SetMatch {
+	seen := map[string]struct{}{}
+	result := []SetMatch{}
+	for pos := 0; pos <= len(str) && len(seen) < len(set.patterns); {
+		loc := set.from.FindAt(str, pos, len(str))
+		if loc == nil {
+			break
+		}
+		for _, pattern := range set.patterns {
+			// Group 1 of from is the combined expression, so every tag moves up by one.
+			if loc[2*(pattern.tag+1)] < 0 {
+				continue
+			}
+			if _, exists := seen[pattern.name]; !exists {
+				seen[pattern.name] = struct{}{}
+				result = append(result, pattern.match(str, loc, pattern.tag+1))
+			}
+			break
+		}
+		pos = loc[2]
+		if pos == len(str) {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(str[pos:])
+		pos += size
+	}
+	return result
+}
+
+// Returns up to n (all if n < 0) non-overlapping matches, each tagged with its pattern name.
+func (set *Set) FindAll(str string, n int) []SetMatch {
+	result := []SetMatch{}
+	for _, loc := range set.re.FindAllStringSubmatchIndex(str, n) {
+		for _, pattern := range set.patterns {
+			if loc[2*pattern.tag] < 0 {
+				continue
+			}
+			result = append(result, pattern.match(str, loc, pattern.tag))
+			break
+		}
+	}
+	return result
+}
+
+// The pattern's match in submatch indexes loc, where its tag capture is group tag.
+func (pattern setPattern) match(str string, loc []int, tag int) SetMatch {
+	match := SetMatch{Name: pattern.name, Start: loc[2*tag], End: loc[2*tag+1], Captures: map[string]string{}}
+	for i, group := range pattern.groups {
+		if loc[2*(tag+group)] >= 0 {
+			match.Captures[pattern.names[i]] = str[loc[2*(tag+group)]:loc[2*(tag+group)+1]]
+		}
+	}
+	return match
+}
+
+// Names of the patterns in priority order.
+func (set *Set) Names() []string {
+	names := make([]string, len(set.patterns))
+	for i, pattern := range set.patterns {
+		names[i] = pattern.name
+	}
+	return names
+}
@@ -2,9 +2,7 @@ package lirex
 
 import (
 	"fmt"
-	"reflect"
 	"regexp"
-	"strings"
 )
 
 type ExpTreeNode []Node
@@ -22,12 +20,18 @@ type Options struct {
 	DotMatchesNewline bool
 	ShowWarnings      bool
 	AllowRedundant    bool
+	// Expansion depth of recursive Rule()s
+	MaxRecursion uint
 }
 type CompileContext struct {
 	groupNames     map[string]struct{}
 	helpersUsed    map[string]struct{}
 	showWarnings   bool
 	allowRedundant bool
+	grammar        *grammar
+	maxRecursion   uint
+	// Prefixes of the enclosing Namespace()s, outermost first
+	namespace []string
 }
 type ExplainContext struct {
 	indent uint
@@ -53,7 +57,13 @@ func (tree ExpTreeNode) Compile(opts Options) (*regexp.Regexp, error) {
 		helpersUsed:    make(map[string]struct{}),
 		showWarnings:   opts.ShowWarnings,
 		allowRedundant: opts.AllowRedundant,
+		maxRecursion:   opts.MaxRecursion,
 	}
+	g, err := newGrammar(tree, opts.MaxRecursion)
+	if err != nil {
+		return nil, err
+	}
+	ctx.grammar = g
 	result, err := compileNodes(tree, ctx)
 	if err != nil {
 		return nil, err
@@ -71,50 +81,8 @@ func (tree ExpTreeNode) MustCompile(opts Options) *regexp.Regexp {
 	return result
 }
 
-func yellow(s string) string {
-	return "\033[33m" + s + "\033[0m"
-}
-func red(s string) string {
-	return "\033[31m" + s + "\033[0m"
-}
-func green(s string) string {
-	return "\033[32m" + s + "\033[0m"
-}
-func bold(s string) string {
-	return "\033[1m" + s + "\033[0m"
-}
-func (tree ExpTreeNode) Explain(opts Options) {
-	ctx := &CompileContext{groupNames: make(map[string]struct{}), showWarnings: true}
-
-	var b strings.Builder
-	for _, child := range tree {
-		t := reflect.TypeOf(child)
-		prefix := t.String()
-		compiled, err := child.compile(ctx)
-		if err != nil {
-			compiled = red("Failed to compile: " + err.Error())
-		}
-		fmt.Println(compiled)
-		b.WriteString(prefix + ": " + bold(compiled) + "\n")
-	}
-
-	// ctx := &CompileContext{
-	// 	groupNames:     make(map[string]struct{}),
-	// 	helpersUsed:    make(map[string]struct{}),
-	// 	showWarnings:   opts.ShowWarnings,
-	// 	allowRedundant: opts.AllowRedundant,
-	// }
-	result, err := compileNodes(tree, ctx)
-	if err != nil {
-	}
-	if result == "" {
-	}
-	// return regexp.Compile(mode + result)
-	fmt.Println(b.String())
-}
-
 func FindCaptures(re *regexp.Regexp, str string) (map[string][]string, bool) {
-	all := re.FindAllStringSubmatch(str, -1)
+	all := re.FindAllStringSubmatchIndex(str, -1)
 	if len(all) == 0 {
 		return nil, false
 	}
@@ -124,10 +92,24 @@ func FindCaptures(re *regexp.Regexp, str string) (map[string][]string, bool) {
 	}
 
 	captures := make(map[string][]string, len(names)-1)
-	for _, match := range all {
-		for i := 1; i < len(match); i++ { // skip index 0 (whole match)
+	for _, loc := range all {
+		// Groups sharing a name (List, ...) add one value per match: the one that participated.
+		participated := map[string]bool{}
+		for i := 1; i < len(names); i++ { // skip index 0 (whole match)
 			name := names[i]
-			captures[name] = append(captures[name], match[i])
+			value := ""
+			if loc[2*i] >= 0 {
+				value = str[loc[2*i]:loc[2*i+1]]
+			}
+			if _, seen := participated[name]; seen && name != "" {
+				if !participated[name] && loc[2*i] >= 0 {
+					captures[name][len(captures[name])-1] = value
+					participated[name] = true
+				}
+				continue
+			}
+			participated[name] = loc[2*i] >= 0
+			captures[name] = append(captures[name], value)
 		}
 	}
 
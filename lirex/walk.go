@@ -0,0 +1,77 @@
+package lirex
+
+// Copy of a container node with its children replaced. Children of repeats must be Repeatable.
+func withChildNodes(node Node, children []Node) Node {
+	switch node := node.(type) {
+	case SeqNode:
+		node.nodes = children
+		return node
+	case GroupNode:
+		node.children = children
+		return node
+	case OrNode:
+		node.children = children
+		return node
+	case CaptureNode:
+		node.children = children
+		return node
+	case RuleNode:
+		node.children = children
+		return node
+	case NamespaceNode:
+		node.children = children
+		return node
+	case AtLeastRepeatNode:
+		node.child = children[0].(Repeatable)
+		return node
+	case ExactlyRepeatNode:
+		node.child = children[0].(Repeatable)
+		return node
+	case BetweenRepeatNode:
+		node.child = children[0].(Repeatable)
+		return node
+	case OptionalRepeatNode:
+		node.child = children[0].(Repeatable)
+		return node
+	case ListNode:
+		node.item, node.sep = children[0], children[1]
+		return node
+	case AnyOrderNode:
+		node.children = children
+		return node
+	}
+	return node
+}
+
+// Copy of nodes with the node at path (child indexes from the root) replaced by fn(node).
+func replaceAt(nodes []Node, path []int, fn func(Node) Node) []Node {
+	result := append([]Node(nil), nodes...)
+	i := path[0]
+	if len(path) == 1 {
+		result[i] = fn(result[i])
+		return result
+	}
+	result[i] = withChildNodes(result[i], replaceAt(childNodes(result[i]), path[1:], fn))
+	return result
+}
+
+// Copy of node with every Capture turned into a Group, for reusing captured fragments
+// inside another capture.
+func uncaptured(node Node) Node {
+	if capture, ok := node.(CaptureNode); ok {
+		return Group(Seq(uncapturedNodes(capture.children)...))
+	}
+	children := childNodes(node)
+	if children == nil {
+		return node
+	}
+	return withChildNodes(node, uncapturedNodes(children))
+}
+
+func uncapturedNodes(nodes []Node) []Node {
+	result := make([]Node, len(nodes))
+	for i, node := range nodes {
+		result[i] = uncaptured(node)
+	}
+	return result
+}
@@ -0,0 +1,319 @@
+// Package peg is a small packrat parser over parsing expression grammars whose terminals are
+// lirex expressions, for inputs regular expressions can't describe.
+package peg
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	lx "lirex/lirex"
+)
+
+type Expr interface {
+	parse(p *parser, pos int) (int, []*Node, bool)
+}
+
+// TERMINALS ----------------------------------------------------------------------------
+type termExpr struct {
+	tree  lx.ExpTreeNode
+	label string
+}
+
+// A terminal compiled with the Options of one grammar; terms can be shared between grammars.
+type compiledTerm struct {
+	re    *lx.AnchoredRegexp
+	label string
+}
+
+// Matches tree anchored at the current position. Reported as the regex in error messages.
+func Term(tree lx.ExpTreeNode) Expr { return &termExpr{tree: tree} }
+
+// Matches s literally. Reported as the quoted string in error messages.
+func Lit(s string) Expr { return &termExpr{tree: lx.Exp(lx.Lit(s)), label: strconv.Quote(s)} }
+
+// COMBINATORS --------------------------------------------------------------------------
+type seqExpr struct{ exprs []Expr }
+type choiceExpr struct{ exprs []Expr }
+type repeatExpr struct {
+	expr Expr
+	min  int
+	max  int // -1 = unbounded
+}
+type predicateExpr struct {
+	expr   Expr
+	negate bool
+}
+type refExpr struct{ name string }
+
+// All exprs in order.
+func Seq(exprs ...Expr) Expr { return &seqExpr{exprs: exprs} }
+
+// Ordered choice: the first expr that matches wins, later ones aren't tried.
+func Choice(exprs ...Expr) Expr { return &choiceExpr{exprs: exprs} }
+
+func ZeroOrMore(expr Expr) Expr { return &repeatExpr{expr: expr, min: 0, max: -1} }
+func OneOrMore(expr Expr) Expr  { return &repeatExpr{expr: expr, min: 1, max: -1} }
+func Optional(expr Expr) Expr   { return &repeatExpr{expr: expr, min: 0, max: 1} }
+
+// Succeeds if expr matches here, without consuming input.
+func And(expr Expr) Expr { return &predicateExpr{expr: expr} }
+
+// Succeeds if expr doesn't match here, without consuming input.
+func Not(expr Expr) Expr { return &predicateExpr{expr: expr, negate: true} }
+
+// Applies the grammar rule name. Every rule application becomes a Node of the parse tree.
+func Ref(name string) Expr { return &refExpr{name: name} }
+
+// GRAMMAR ------------------------------------------------------------------------------
+type Grammar struct {
+	start string
+	rules map[string]Expr
+	terms map[*termExpr]compiledTerm
+}
+
+// Parse tree node for one rule application.
+type Node struct {
+	Rule       string
+	Start, End int
+	Text       string
+	Children   []*Node
+}
+
+type SyntaxError struct {
+	Offset, Line, Column int
+	// Terminals that could have matched at Offset, sorted. Empty if only an And or Not failed there.
+	Expected []string
+}
+
+func (err *SyntaxError) Error() string {
+	if len(err.Expected) == 0 {
+		return fmt.Sprintf("Lirex PEG: syntax error at line %d, column %d (offset %d): unexpected input.",
+			err.Line, err.Column, err.Offset)
+	}
+	return fmt.Sprintf("Lirex PEG: syntax error at line %d, column %d (offset %d): expected %s.",
+		err.Line, err.Column, err.Offset, strings.Join(err.Expected, " or "))
+}
+
+// Compiles every terminal with opts and checks that all Refs name existing rules.
+func NewGrammar(start string, rules map[string]Expr, opts lx.Options) (*Grammar, error) {
+	if _, exists := rules[start]; !exists {
+		return nil, fmt.Errorf("Lirex PEG: unknown start rule '%s'.", start)
+	}
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	terms := map[*termExpr]compiledTerm{}
+	var prepare func(expr Expr, rule string) error
+	prepare = func(expr Expr, rule string) error {
+		switch expr := expr.(type) {
+		case *termExpr:
+			if _, compiled := terms[expr]; compiled {
+				return nil
+			}
+			re, err := expr.tree.Compile(opts)
+			if err != nil {
+				return fmt.Errorf("Lirex PEG: rule '%s': %w", rule, err)
+			}
+			term := compiledTerm{re: lx.Anchor(re), label: expr.label}
+			if term.label == "" {
+				term.label = re.String()
+			}
+			terms[expr] = term
+		case *seqExpr:
+			for _, sub := range expr.exprs {
+				if err := prepare(sub, rule); err != nil {
+					return err
+				}
+			}
+		case *choiceExpr:
+			for _, sub := range expr.exprs {
+				if err := prepare(sub, rule); err != nil {
+					return err
+				}
+			}
+		case *repeatExpr:
+			return prepare(expr.expr, rule)
+		case *predicateExpr:
+			return prepare(expr.expr, rule)
+		case *refExpr:
+			if _, exists := rules[expr.name]; !exists {
+				return fmt.Errorf("Lirex PEG: rule '%s' references unknown rule '%s'.", rule, expr.name)
+			}
+		case nil:
+			return fmt.Errorf("Lirex PEG: rule '%s' contains a nil expression.", rule)
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := prepare(rules[name], name); err != nil {
+			return nil, err
+		}
+	}
+	return &Grammar{start: start, rules: rules, terms: terms}, nil
+}
+
+// Parses the whole input from the start rule. Failures are reported at the furthest
+// position any terminal was tried, with the terminals expected there.
+func (g *Grammar) Parse(input string) (*Node, error) {
+	p := &parser{grammar: g, input: input, memo: map[memoKey]*memoEntry{}}
+	end, nodes, ok := (&refExpr{name: g.start}).parse(p, 0)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if ok && end == len(input) {
+		return nodes[0], nil
+	}
+	if ok && end >= p.farthest {
+		// Everything parsed, but input remains.
+		p.farthest = end
+		p.expected = map[string]struct{}{"end of input": {}}
+	}
+	return nil, p.syntaxError()
+}
+
+// PARSER -------------------------------------------------------------------------------
+type memoKey struct {
+	rule string
+	pos  int
+}
+type memoEntry struct {
+	end        int
+	node       *Node
+	ok         bool
+	inProgress bool
+}
+
+type parser struct {
+	grammar *Grammar
+	input   string
+	memo    map[memoKey]*memoEntry
+	// Furthest failure position and the terminals expected there
+	farthest int
+	expected map[string]struct{}
+	// > 0 inside And/Not, whose failures aren't expectations
+	predicates int
+	err        error
+}
+
+// Records a failure at pos; label is the terminal expected there, or "" for a failed predicate.
+func (p *parser) fail(pos int, label string) {
+	if p.predicates > 0 || pos < p.farthest {
+		return
+	}
+	if pos > p.farthest || p.expected == nil {
+		p.farthest = pos
+		p.expected = map[string]struct{}{}
+	}
+	if label != "" {
+		p.expected[label] = struct{}{}
+	}
+}
+func (p *parser) syntaxError() *SyntaxError {
+	expected := make([]string, 0, len(p.expected))
+	for label := range p.expected {
+		expected = append(expected, label)
+	}
+	sort.Strings(expected)
+	line, column := 1, 1
+	for _, r := range p.input[:p.farthest] {
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return &SyntaxError{Offset: p.farthest, Line: line, Column: column, Expected: expected}
+}
+
+func (e *termExpr) parse(p *parser, pos int) (int, []*Node, bool) {
+	term := p.grammar.terms[e]
+	loc := term.re.FindAt(p.input, pos, len(p.input))
+	if loc == nil {
+		p.fail(pos, term.label)
+		return pos, nil, false
+	}
+	return loc[1], nil, true
+}
+func (e *seqExpr) parse(p *parser, pos int) (int, []*Node, bool) {
+	nodes := []*Node{}
+	for _, expr := range e.exprs {
+		end, children, ok := expr.parse(p, pos)
+		if !ok {
+			return pos, nil, false
+		}
+		nodes = append(nodes, children...)
+		pos = end
+	}
+	return pos, nodes, true
+}
+func (e *choiceExpr) parse(p *parser, pos int) (int, []*Node, bool) {
+	for _, expr := range e.exprs {
+		if end, nodes, ok := expr.parse(p, pos); ok {
+			return end, nodes, true
+		}
+	}
+	return pos, nil, false
+}
+func (e *repeatExpr) parse(p *parser, pos int) (int, []*Node, bool) {
+	nodes := []*Node{}
+	start := pos
+	for count := 0; e.max < 0 || count < e.max; count++ {
+		end, children, ok := e.expr.parse(p, pos)
+		if !ok {
+			if count < e.min {
+				return start, nil, false
+			}
+			break
+		}
+		nodes = append(nodes, children...)
+		// An empty match would repeat forever.
+		if end == pos {
+			break
+		}
+		pos = end
+	}
+	return pos, nodes, true
+}
+func (e *predicateExpr) parse(p *parser, pos int) (int, []*Node, bool) {
+	p.predicates++
+	_, _, ok := e.expr.parse(p, pos)
+	p.predicates--
+	if ok == e.negate {
+		p.fail(pos, "")
+		return pos, nil, false
+	}
+	return pos, nil, true
+}
+func (e *refExpr) parse(p *parser, pos int) (int, []*Node, bool) {
+	key := memoKey{rule: e.name, pos: pos}
+	if entry, exists := p.memo[key]; exists {
+		if entry.inProgress {
+			if p.err == nil {
+				p.err = fmt.Errorf("Lirex PEG: rule '%s' is left-recursive.", e.name)
+			}
+			return pos, nil, false
+		}
+		if !entry.ok {
+			return pos, nil, false
+		}
+		return entry.end, []*Node{entry.node}, true
+	}
+
+	entry := &memoEntry{inProgress: true}
+	p.memo[key] = entry
+	end, children, ok := p.grammar.rules[e.name].parse(p, pos)
+	entry.inProgress = false
+	entry.ok = ok
+	if !ok {
+		return pos, nil, false
+	}
+	entry.end = end
+	entry.node = &Node{Rule: e.name, Start: pos, End: end, Text: p.input[pos:end], Children: children}
+	return end, []*Node{entry.node}, true
+}
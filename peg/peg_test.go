@@ -0,0 +1,186 @@
+package peg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	lx "lirex/lirex"
+)
+
+// Rule names of the tree with their children in parentheses, e.g. "expr(term(number))".
+func shape(node *Node) string {
+	children := []string{}
+	for _, child := range node.Children {
+		children = append(children, shape(child))
+	}
+	if len(children) == 0 {
+		return node.Rule
+	}
+	return node.Rule + "(" + strings.Join(children, " ") + ")"
+}
+
+var (
+	number = Term(lx.Exp(lx.Digit.AtLeast(1)))
+	ident  = Term(lx.Exp(lx.Latin.AtLeast(1)))
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules map[string]Expr
+		input string
+		want  string
+		err   string
+	}{
+		{
+			name: "sum",
+			rules: map[string]Expr{
+				"expr":   Seq(Ref("term"), ZeroOrMore(Seq(Lit("+"), Ref("term")))),
+				"term":   Choice(Ref("number"), Seq(Lit("("), Ref("expr"), Lit(")"))),
+				"number": number,
+			},
+			input: "1+(2+3)",
+			want:  "expr(term(number) term(expr(term(number) term(number))))",
+		},
+		{
+			name:  "ordered choice keeps the first match",
+			rules: map[string]Expr{"start": Choice(Lit("a"), Lit("ab"))},
+			input: "ab",
+			err:   `column 2 (offset 1): expected end of input`,
+		},
+		{
+			name:  "missing operand",
+			rules: map[string]Expr{"start": Seq(number, OneOrMore(Seq(Lit("+"), number)))},
+			input: "1+",
+			err:   `column 3 (offset 2): expected \d+`,
+		},
+		{
+			name:  "expected terminals at the furthest offset",
+			rules: map[string]Expr{"start": Seq(Lit("let"), Lit(" "), Choice(number, ident), Lit(";"))},
+			input: "let =",
+			err:   `column 5 (offset 4): expected [a-zA-Z]+ or \d+`,
+		},
+		{
+			name:  "line and column",
+			rules: map[string]Expr{"start": OneOrMore(Seq(ident, Lit("\n")))},
+			input: "ab\ncd\nef!",
+			err:   `line 3, column 3 (offset 8): expected "\n"`,
+		},
+		{
+			name: "Not excludes keywords",
+			rules: map[string]Expr{
+				"start": Choice(Seq(Lit("if"), Lit(" "), Ref("name")), Ref("name")),
+				"name":  Seq(Not(Seq(Lit("if"), Not(ident))), ident),
+			},
+			input: "if x",
+			want:  "start(name)",
+		},
+		{
+			name: "Not failure is not an expectation",
+			rules: map[string]Expr{
+				"start": Seq(Lit("if "), Ref("name")),
+				"name":  Seq(Not(Seq(Lit("if"), Not(ident))), ident),
+			},
+			input: "if if",
+			err:   `column 4 (offset 3): unexpected input.`,
+		},
+		{
+			name:  "And doesn't consume",
+			rules: map[string]Expr{"start": Seq(And(Lit("ab")), ident)},
+			input: "abc",
+			want:  "start",
+		},
+		{
+			name:  "And failure is not an expectation",
+			rules: map[string]Expr{"start": Seq(Optional(Lit("x")), And(Lit("ab")), ident)},
+			input: "cd",
+			err:   `column 1 (offset 0): expected "x".`,
+		},
+		{
+			name: "left recursion",
+			rules: map[string]Expr{
+				"expr":   Choice(Seq(Ref("expr"), Lit("+"), Ref("number")), Ref("number")),
+				"number": number,
+			},
+			input: "1+2",
+			err:   `rule 'expr' is left-recursive`,
+		},
+	}
+	for _, test := range tests {
+		start := "start"
+		if _, ok := test.rules[start]; !ok {
+			start = "expr"
+		}
+		g, err := NewGrammar(start, test.rules, lx.Options{})
+		if err != nil {
+			t.Fatalf("%s: NewGrammar: %v", test.name, err)
+		}
+		tree, err := g.Parse(test.input)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: Parse(%q) error = %v, want one containing %q", test.name, test.input, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Parse(%q): %v", test.name, test.input, err)
+			continue
+		}
+		if got := shape(tree); got != test.want {
+			t.Errorf("%s: Parse(%q) = %s, want %s", test.name, test.input, got, test.want)
+		}
+	}
+}
+
+func TestNewGrammarErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		rules map[string]Expr
+		err   string
+	}{
+		{"unknown start", "main", map[string]Expr{"start": Lit("a")}, "unknown start rule 'main'"},
+		{"unknown ref", "start", map[string]Expr{"start": Ref("missing")}, "references unknown rule 'missing'"},
+		{"nil expr", "start", map[string]Expr{"start": Seq(Lit("a"), nil)}, "contains a nil expression"},
+		{"bad term", "start", map[string]Expr{"start": Term(lx.Exp(lx.Capture("1x", lx.Digit)))}, "invalid name"},
+	}
+	for _, test := range tests {
+		_, err := NewGrammar(test.start, test.rules, lx.Options{})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: NewGrammar error = %v, want one containing %q", test.name, err, test.err)
+		}
+	}
+}
+
+// A rule is applied once per position: backtracking out of a choice reuses the node parsed
+// by the failed alternative.
+func TestMemoization(t *testing.T) {
+	g, err := NewGrammar("start", map[string]Expr{
+		"start": Choice(Seq(Ref("item"), Lit(";")), Seq(Ref("item"), Lit("."))),
+		"item":  ident,
+	}, lx.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &parser{grammar: g, input: "abc.", memo: map[memoKey]*memoEntry{}}
+	end, nodes, ok := (&refExpr{name: "start"}).parse(p, 0)
+	if !ok || end != 4 {
+		t.Fatalf("parse = %d, %v, want 4, true", end, ok)
+	}
+	keys := []memoKey{}
+	for key := range p.memo {
+		keys = append(keys, key)
+	}
+	if len(keys) != 2 {
+		t.Errorf("memo keys = %v, want start and item at 0", keys)
+	}
+	entry := p.memo[memoKey{rule: "item", pos: 0}]
+	if entry == nil || nodes[0].Children[0] != entry.node {
+		t.Errorf("item node isn't the memoized one")
+	}
+	want := &Node{Rule: "item", Start: 0, End: 3, Text: "abc"}
+	if !reflect.DeepEqual(entry.node, want) {
+		t.Errorf("item node = %+v, want %+v", entry.node, want)
+	}
+}
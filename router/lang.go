@@ -0,0 +1,201 @@
+package router
+
+import (
+	"regexp/syntax"
+	"sort"
+	"unicode"
+)
+
+// LANGUAGES ----------------------------------------------------------------------------
+// Route paths are compared as regular languages by walking the compiled programs of two
+// patterns in lockstep. Empty-width assertions (^, $, \b) are treated as always passing,
+// so overlaps are reported conservatively.
+
+func compileProg(pattern string) (*syntax.Prog, error) {
+	re, err := syntax.Parse(`\A(?:`+pattern+`)\z`, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return syntax.Compile(re.Simplify())
+}
+
+// Rune-consuming instructions reachable from pcs without input, and whether a match is.
+func closure(prog *syntax.Prog, pcs []uint32) ([]uint32, bool) {
+	seen := map[uint32]bool{}
+	result := []uint32{}
+	matched := false
+	var visit func(pc uint32)
+	visit = func(pc uint32) {
+		if seen[pc] {
+			return
+		}
+		seen[pc] = true
+		inst := &prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			visit(inst.Out)
+			visit(inst.Arg)
+		case syntax.InstCapture, syntax.InstNop, syntax.InstEmptyWidth:
+			visit(inst.Out)
+		case syntax.InstMatch:
+			matched = true
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			result = append(result, pc)
+		}
+	}
+	for _, pc := range pcs {
+		visit(pc)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, matched
+}
+
+// [lo, hi] pairs accepted by a rune instruction, case folding expanded.
+func instRanges(inst *syntax.Inst) []rune {
+	switch inst.Op {
+	case syntax.InstRuneAny:
+		return []rune{0, unicode.MaxRune}
+	case syntax.InstRuneAnyNotNL:
+		return []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}
+	}
+	ranges := append([]rune{}, inst.Rune...)
+	if len(ranges) == 1 {
+		ranges = []rune{ranges[0], ranges[0]}
+	}
+	if syntax.Flags(inst.Arg)&syntax.FoldCase != 0 && len(inst.Rune) == 1 {
+		for r := unicode.SimpleFold(inst.Rune[0]); r != inst.Rune[0]; r = unicode.SimpleFold(r) {
+			ranges = append(ranges, r, r)
+		}
+	}
+	return ranges
+}
+
+// One rune from every interval the range boundaries of both instruction sets cut the alphabet into.
+func representatives(a *syntax.Prog, as []uint32, b *syntax.Prog, bs []uint32) []rune {
+	bounds := map[rune]struct{}{0: {}}
+	for _, side := range []struct {
+		prog *syntax.Prog
+		pcs  []uint32
+	}{{a, as}, {b, bs}} {
+		for _, pc := range side.pcs {
+			ranges := instRanges(&side.prog.Inst[pc])
+			for i := 0; i+1 < len(ranges); i += 2 {
+				bounds[ranges[i]] = struct{}{}
+				if ranges[i+1] < unicode.MaxRune {
+					bounds[ranges[i+1]+1] = struct{}{}
+				}
+			}
+		}
+	}
+	runes := make([]rune, 0, len(bounds))
+	for r := range bounds {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
+
+func accepts(inst *syntax.Inst, r rune) bool {
+	ranges := instRanges(inst)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] <= r && r <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Instructions reached from the rune instructions pcs on reading r.
+func step(prog *syntax.Prog, pcs []uint32, r rune) []uint32 {
+	next := []uint32{}
+	for _, pc := range pcs {
+		if accepts(&prog.Inst[pc], r) {
+			next = append(next, prog.Inst[pc].Out)
+		}
+	}
+	return next
+}
+
+func key(pcs []uint32) string {
+	b := make([]byte, 0, len(pcs)*4)
+	for _, pc := range pcs {
+		b = append(b, byte(pc>>24), byte(pc>>16), byte(pc>>8), byte(pc))
+	}
+	return string(b)
+}
+
+// Whether some string is accepted by both programs.
+func intersects(a, b *syntax.Prog) bool {
+	type state struct{ a, b []uint32 }
+	start := state{[]uint32{uint32(a.Start)}, []uint32{uint32(b.Start)}}
+	seen := map[string]bool{}
+	queue := []state{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		as, aMatch := closure(a, current.a)
+		bs, bMatch := closure(b, current.b)
+		if aMatch && bMatch {
+			return true
+		}
+		id := key(as) + "|" + key(bs)
+		if seen[id] || len(as) == 0 || len(bs) == 0 {
+			continue
+		}
+		seen[id] = true
+		for _, r := range representatives(a, as, b, bs) {
+			an, bn := step(a, as, r), step(b, bs, r)
+			if len(an) > 0 && len(bn) > 0 {
+				queue = append(queue, state{an, bn})
+			}
+		}
+	}
+	return false
+}
+
+// Whether every string accepted by a is accepted by b.
+func subsetOf(a, b *syntax.Prog) bool {
+	type state struct{ a, b []uint32 }
+	seen := map[string]bool{}
+	queue := []state{{[]uint32{uint32(a.Start)}, []uint32{uint32(b.Start)}}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		as, aMatch := closure(a, current.a)
+		bs, bMatch := closure(b, current.b)
+		if aMatch && !bMatch {
+			return false
+		}
+		id := key(as) + "|" + key(bs)
+		if seen[id] || len(as) == 0 {
+			continue
+		}
+		seen[id] = true
+		// Representatives over both sides: every rune in an interval leads to the same states.
+		for _, r := range representatives(a, as, b, bs) {
+			if an := step(a, as, r); len(an) > 0 {
+				queue = append(queue, state{an, step(b, bs, r)})
+			}
+		}
+	}
+	return true
+}
+
+// Literal runes every match must contain, a measure of how specific a route is.
+func literalCount(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpConcat, syntax.OpCapture:
+		count := 0
+		for _, sub := range re.Sub {
+			count += literalCount(sub)
+		}
+		return count
+	case syntax.OpPlus:
+		return literalCount(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * literalCount(re.Sub[0])
+	}
+	return 0
+}
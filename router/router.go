@@ -0,0 +1,215 @@
+// Package router is an http.Handler that dispatches requests on lirex path expressions
+// and route templates, rejecting ambiguous routes when they are registered.
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+
+	lx "lirex/lirex"
+)
+
+type route struct {
+	method   string
+	path     lx.Node
+	re       *regexp.Regexp
+	prog     *syntax.Prog
+	literals int
+	handler  http.Handler
+	// Overlapping routes this one is preferred over
+	outranks map[*route]bool
+}
+
+func (r *route) String() string {
+	method := r.method
+	if method == "" {
+		method = "*"
+	}
+	if template, ok := r.path.(lx.RouteNode); ok {
+		return method + " " + template.String()
+	}
+	return method + " " + r.re.String()
+}
+
+type Router struct {
+	// Served when no route matches the path. Defaults to http.NotFound.
+	NotFound http.Handler
+
+	opts   lx.Options
+	mu     sync.RWMutex
+	routes []*route
+}
+
+func New(opts lx.Options) *Router {
+	return &Router{opts: opts}
+}
+
+// Registers handler for method ("" for any) and a path expression, usually an lx.Route().
+// The path must match the whole request path. A route that overlaps an existing one is
+// accepted only if one of them is more specific:
+//   - its paths are a subset of the other's, e.g. /users/{id:int} over /users/{name}
+//   - otherwise it has more literal characters, e.g. /users/me over /users/{name}
+//   - for the same paths, a route for one method over a route for any method
+func (router *Router) Handle(method string, path lx.Node, handler http.Handler) error {
+	compiled, err := lx.Exp(path).Compile(router.opts)
+	if err != nil {
+		return err
+	}
+	pattern := compiled.String()
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return err
+	}
+	prog, err := compileProg(pattern)
+	if err != nil {
+		return err
+	}
+	added := &route{
+		method:   strings.ToUpper(method),
+		path:     path,
+		re:       regexp.MustCompile(`\A(?:` + pattern + `)\z`),
+		prog:     prog,
+		literals: literalCount(parsed),
+		handler:  handler,
+		outranks: map[*route]bool{},
+	}
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	outranked := []*route{}
+	for _, existing := range router.routes {
+		if added.method != existing.method && added.method != "" && existing.method != "" {
+			continue
+		}
+		if !intersects(added.prog, existing.prog) {
+			continue
+		}
+		preferred, err := moreSpecific(added, existing)
+		if err != nil {
+			return err
+		}
+		if preferred == added {
+			added.outranks[existing] = true
+		} else {
+			outranked = append(outranked, existing)
+		}
+	}
+	// Only touch existing routes once the new one is known to be accepted.
+	for _, existing := range outranked {
+		existing.outranks[added] = true
+	}
+	router.routes = append(router.routes, added)
+	return nil
+}
+
+func (router *Router) HandleFunc(method string, path lx.Node, handler func(http.ResponseWriter, *http.Request)) error {
+	return router.Handle(method, path, http.HandlerFunc(handler))
+}
+
+// Which of two overlapping routes wins, or an error if neither does.
+func moreSpecific(a, b *route) (*route, error) {
+	aInB, bInA := subsetOf(a.prog, b.prog), subsetOf(b.prog, a.prog)
+	switch {
+	case aInB && bInA:
+		if a.method == b.method {
+			return nil, fmt.Errorf("Lirex Router: %s is already registered as %s.", a, b)
+		}
+		if a.method != "" {
+			return a, nil
+		}
+		return b, nil
+	case aInB:
+		return a, nil
+	case bInA:
+		return b, nil
+	case a.literals > b.literals:
+		return a, nil
+	case b.literals > a.literals:
+		return b, nil
+	}
+	return nil, fmt.Errorf("Lirex Router: %s overlaps %s and neither is more specific.", a, b)
+}
+
+func (router *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	router.mu.RLock()
+	var best *route
+	allowed := map[string]bool{}
+	for _, r := range router.routes {
+		if !r.re.MatchString(req.URL.Path) {
+			continue
+		}
+		if r.method != "" && r.method != req.Method {
+			allowed[r.method] = true
+			continue
+		}
+		if best == nil || r.outranks[best] {
+			best = r
+		}
+	}
+	router.mu.RUnlock()
+
+	if best == nil {
+		if len(allowed) > 0 {
+			methods := make([]string, 0, len(allowed))
+			for method := range allowed {
+				methods = append(methods, method)
+			}
+			sort.Strings(methods)
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		notFound := router.NotFound
+		if notFound == nil {
+			notFound = http.NotFoundHandler()
+		}
+		notFound.ServeHTTP(w, req)
+		return
+	}
+
+	match, _ := lx.FindMatch(best.re, req.URL.Path)
+	p := params{match: match}
+	if template, ok := best.path.(lx.RouteNode); ok {
+		values, err := template.Values(match)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.values = values
+	}
+	best.handler.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), paramsKey{}, p)))
+}
+
+// PARAMS -------------------------------------------------------------------------------
+type paramsKey struct{}
+
+type params struct {
+	match  lx.Match
+	values map[string]any
+}
+
+// Returns the named capture of the matched route's path, or "" if there is none.
+func PathValue(req *http.Request, name string) string {
+	p, _ := req.Context().Value(paramsKey{}).(params)
+	return p.match.Get(name)
+}
+
+// Returns the typed value of a route template param, e.g. an int for {id:int}.
+// Captures of plain expressions are returned as strings.
+func PathParam(req *http.Request, name string) (any, bool) {
+	p, _ := req.Context().Value(paramsKey{}).(params)
+	if value, ok := p.values[name]; ok {
+		return value, true
+	}
+	value, ok := p.match.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+	return value, true
+}
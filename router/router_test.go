@@ -0,0 +1,113 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	lx "lirex/lirex"
+)
+
+// Handler writing its name and the given params.
+func echo(name string, params ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, name)
+		for _, param := range params {
+			value, _ := PathParam(req, param)
+			fmt.Fprintf(w, " %s=%v(%T)", param, value, value)
+		}
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	router := New(lx.Options{})
+	routes := []struct {
+		method  string
+		path    lx.Node
+		handler http.Handler
+	}{
+		{"GET", lx.Route("/users/{id:int}"), echo("byID", "id")},
+		{"GET", lx.Route("/users/{name}"), echo("byName", "name")},
+		{"GET", lx.Route("/users/me"), echo("me")},
+		{"POST", lx.Route("/users"), echo("create")},
+		{"", lx.Route("/files/{path:rest}"), echo("anyFile", "path")},
+		{"GET", lx.Route("/files/{path:rest}"), echo("getFile", "path")},
+		{"GET", lx.Seq(lx.Lit("/v"), lx.Capture("version", lx.Digit.AtLeast(1))), echo("version", "version")},
+	}
+	for _, route := range routes {
+		if err := router.Handle(route.method, route.path, route.handler); err != nil {
+			t.Fatalf("Handle(%q, %v): %v", route.method, route.path, err)
+		}
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+		allow        string
+	}{
+		{"GET", "/users/42", 200, "byID id=42(int)", ""},
+		{"GET", "/users/-7", 200, "byID id=-7(int)", ""},
+		{"GET", "/users/bob", 200, "byName name=bob(string)", ""},
+		{"GET", "/users/me", 200, "me", ""},
+		{"POST", "/users", 200, "create", ""},
+		{"GET", "/files/a/b.txt", 200, "getFile path=a/b.txt(string)", ""},
+		{"DELETE", "/files/a/b.txt", 200, "anyFile path=a/b.txt(string)", ""},
+		{"GET", "/v2", 200, "version version=2(string)", ""},
+		{"GET", "/users", 405, "Method Not Allowed\n", "POST"},
+		{"DELETE", "/users/42", 405, "Method Not Allowed\n", "GET"},
+		{"GET", "/users/42/extra", 404, "404 page not found\n", ""},
+		{"GET", "/v", 404, "404 page not found\n", ""},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, nil))
+		if rec.Code != test.status || rec.Body.String() != test.body || rec.Header().Get("Allow") != test.allow {
+			t.Errorf("%s %s = %d %q (Allow %q), want %d %q (Allow %q)", test.method, test.path,
+				rec.Code, rec.Body.String(), rec.Header().Get("Allow"), test.status, test.body, test.allow)
+		}
+	}
+}
+
+func TestHandleRejectsAmbiguousRoutes(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second lx.Node
+		method        string
+	}{
+		{"same route", lx.Route("/users/{id:int}"), lx.Route("/users/{n:int}"), "GET"},
+		{"same literal count", lx.Route("/a/{x}"), lx.Route("/{y}/b"), "GET"},
+		{"any method twice", lx.Route("/files"), lx.Route("/files"), ""},
+	}
+	for _, test := range tests {
+		router := New(lx.Options{})
+		if err := router.Handle(test.method, test.first, http.NotFoundHandler()); err != nil {
+			t.Fatalf("%s: first route: %v", test.name, err)
+		}
+		if err := router.Handle(test.method, test.second, http.NotFoundHandler()); err == nil {
+			t.Errorf("%s: second route accepted, want an error", test.name)
+		}
+	}
+}
+
+func TestHandleAcceptsDisjointRoutes(t *testing.T) {
+	router := New(lx.Options{})
+	for _, path := range []string{"/a/{x:int}", "/a/{x:slug}/b", "/b/{x}"} {
+		if err := router.Handle("GET", lx.Route(path), http.NotFoundHandler()); err != nil {
+			t.Errorf("Handle(%q): %v", path, err)
+		}
+	}
+	if err := router.Handle("POST", lx.Route("/a/{x:int}"), http.NotFoundHandler()); err != nil {
+		t.Errorf("same path for another method: %v", err)
+	}
+}
+
+func TestHandleRejectsBadTemplates(t *testing.T) {
+	router := New(lx.Options{})
+	for _, path := range []string{"/a/{}", "/a/{:int}", "/a/{1x}", "/a/{a-b}", "/a/{x}/{x}", "/a/{x", "/a/x}", "/a/{x:nope}"} {
+		if err := router.Handle("GET", lx.Route(path), http.NotFoundHandler()); err == nil {
+			t.Errorf("Handle(%q) accepted, want an error", path)
+		}
+	}
+}